@@ -0,0 +1,110 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// Balance is the credit balance of the account.
+type Balance struct {
+	Balance  float64 `json:"balance"`
+	Currency string  `json:"currency,omitempty"`
+}
+
+// UsageGroup selects how GetUsage breaks down spend.
+type UsageGroup string
+
+// Usage groupings.
+const (
+	// UsageTotal reports only the totals.
+	UsageTotal UsageGroup = ""
+	// UsageByModel reports spend per model.
+	UsageByModel UsageGroup = "model"
+	// UsageByDay reports spend per day, keyed by date (YYYY-MM-DD, UTC).
+	UsageByDay UsageGroup = "day"
+)
+
+// Usage is the spend of the account over a time range.
+type Usage struct {
+	From          time.Time   `json:"start_time"`
+	To            time.Time   `json:"end_time"`
+	GroupBy       UsageGroup  `json:"group_by,omitempty"`
+	Currency      string      `json:"currency,omitempty"`
+	TotalCost     float64     `json:"total_cost"`
+	TotalRequests int         `json:"total_requests"`
+	Items         []UsageItem `json:"items,omitempty"`
+}
+
+// UsageItem is the spend of one group, such as one model.
+type UsageItem struct {
+	// Key is the model ID or date the item covers.
+	Key      string  `json:"key"`
+	Requests int     `json:"requests"`
+	Cost     float64 `json:"cost"`
+}
+
+// GetBalance returns the credit balance of the account.
+//
+// Example:
+//
+//	balance, err := client.GetBalance(ctx)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	if balance.Balance < 10 {
+//	    log.Printf("low balance: %.2f %s", balance.Balance, balance.Currency)
+//	}
+func (c *Client) GetBalance(ctx context.Context) (*Balance, error) {
+	var balance Balance
+	if err := c.getJSON(ctx, "/api/v3/balance", &balance); err != nil {
+		return nil, fmt.Errorf("failed to get balance: %w", err)
+	}
+	return &balance, nil
+}
+
+// GetUsage returns the spend of the account between from and to, broken down
+// by groupBy.
+//
+// Example:
+//
+//	now := time.Now()
+//	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+//	usage, err := client.GetUsage(ctx, monthStart, now, api.UsageByModel)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	for _, item := range usage.Items {
+//	    fmt.Println(item.Key, item.Requests, item.Cost)
+//	}
+func (c *Client) GetUsage(ctx context.Context, from, to time.Time, groupBy UsageGroup) (*Usage, error) {
+	if !to.After(from) {
+		return nil, errors.New("usage range must end after it starts")
+	}
+	switch groupBy {
+	case UsageTotal, UsageByModel, UsageByDay:
+	default:
+		return nil, fmt.Errorf("unknown usage grouping: %s", groupBy)
+	}
+
+	query := url.Values{}
+	query.Set("start_time", from.UTC().Format(time.RFC3339))
+	query.Set("end_time", to.UTC().Format(time.RFC3339))
+	if groupBy != UsageTotal {
+		query.Set("group_by", string(groupBy))
+	}
+
+	var usage Usage
+	if err := c.getJSON(ctx, "/api/v3/usage?"+query.Encode(), &usage); err != nil {
+		return nil, fmt.Errorf("failed to get usage: %w", err)
+	}
+	if usage.From.IsZero() {
+		usage.From, usage.To = from, to
+	}
+	if usage.GroupBy == UsageTotal {
+		usage.GroupBy = groupBy
+	}
+	return &usage, nil
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetBalance(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v3/balance" || r.Header.Get("Authorization") != "Bearer test-key" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"code":200,"data":{"balance":12.5,"currency":"USD"}}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(WithAPIKey("test-key"), WithBaseURL(server.URL))
+	balance, err := client.GetBalance(context.Background())
+	if err != nil {
+		t.Fatalf("GetBalance error: %v", err)
+	}
+	if balance.Balance != 12.5 || balance.Currency != "USD" {
+		t.Errorf("unexpected balance: %+v", balance)
+	}
+}
+
+func TestGetUsage(t *testing.T) {
+	var query map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v3/usage" {
+			http.NotFound(w, r)
+			return
+		}
+		query = map[string]string{}
+		for k := range r.URL.Query() {
+			query[k] = r.URL.Query().Get(k)
+		}
+		w.Write([]byte(`{"code":200,"data":{"currency":"USD","total_cost":1.25,"total_requests":30,"items":[
+			{"key":"wavespeed-ai/z-image/turbo","requests":25,"cost":0.125},
+			{"key":"wavespeed-ai/wan-2.1/t2v-480p","requests":5,"cost":1.125}
+		]}}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(WithAPIKey("test-key"), WithBaseURL(server.URL))
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 6, 1, 2, 0, 0, 0, time.FixedZone("CEST", 2*60*60))
+
+	usage, err := client.GetUsage(context.Background(), from, to, UsageByModel)
+	if err != nil {
+		t.Fatalf("GetUsage error: %v", err)
+	}
+	if query["start_time"] != "2024-05-01T00:00:00Z" || query["end_time"] != "2024-06-01T00:00:00Z" || query["group_by"] != "model" {
+		t.Errorf("unexpected query: %v", query)
+	}
+	if usage.TotalCost != 1.25 || usage.TotalRequests != 30 || len(usage.Items) != 2 || usage.Items[1].Cost != 1.125 {
+		t.Errorf("unexpected usage: %+v", usage)
+	}
+	if !usage.From.Equal(from) || !usage.To.Equal(to) || usage.GroupBy != UsageByModel {
+		t.Errorf("expected the requested range and grouping, got %+v", usage)
+	}
+
+	if _, err := client.GetUsage(context.Background(), from, to, UsageTotal); err != nil {
+		t.Fatalf("GetUsage error: %v", err)
+	}
+	if _, ok := query["group_by"]; ok {
+		t.Errorf("expected no grouping in query, got %v", query)
+	}
+
+	if _, err := client.GetUsage(context.Background(), to, from, UsageByModel); err == nil || !strings.Contains(err.Error(), "must end after") {
+		t.Errorf("expected range error, got %v", err)
+	}
+	if _, err := client.GetUsage(context.Background(), from, to, "team"); err == nil || !strings.Contains(err.Error(), "unknown usage grouping") {
+		t.Errorf("expected grouping error, got %v", err)
+	}
+}
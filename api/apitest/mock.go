@@ -0,0 +1,293 @@
+// Package apitest provides an in-memory implementation of api.Runner for
+// testing code that talks to WaveSpeed without an HTTP server.
+//
+// Example:
+//
+//	mock := apitest.NewMock()
+//	mock.Enqueue("wavespeed-ai/z-image/turbo", apitest.Result{Outputs: []any{"https://example.com/cat.png"}})
+//
+//	svc := NewService(mock) // accepts api.Runner
+//	svc.Generate("Cat")
+//
+//	if calls := mock.Calls(); len(calls) != 1 {
+//	    t.Fatalf("expected 1 call, got %d", len(calls))
+//	}
+package apitest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/WaveSpeedAI/wavespeed-go/api"
+)
+
+// Call records a single method invocation on a Mock.
+type Call struct {
+	Method string
+	Model  string
+	Input  map[string]any
+	TaskID string
+	File   string
+}
+
+// Result is a scripted prediction returned by a Mock.
+type Result struct {
+	// TaskID is the ID reported for the prediction. A sequential ID is
+	// generated when empty.
+	TaskID string
+	// Outputs are returned when Err is nil.
+	Outputs []any
+	// Err makes the prediction fail with this error.
+	Err error
+	// SubmitErr makes Submit (and therefore Run) fail before a task is created.
+	SubmitErr error
+}
+
+// Mock is an in-memory api.Runner that records calls and returns scripted
+// predictions. It is safe for concurrent use.
+type Mock struct {
+	mu       sync.Mutex
+	calls    []Call
+	queues   map[string][]Result
+	tasks    map[string]Result
+	uploads  map[string]string
+	canceled map[string]bool
+	nextID   int
+
+	// Default is returned for models without queued results.
+	Default Result
+}
+
+var _ api.Runner = (*Mock)(nil)
+
+// ErrCanceled is returned by Wait for tasks canceled through the mock.
+var ErrCanceled = errors.New("apitest: task canceled")
+
+// NewMock creates an empty Mock. Unscripted models complete with no outputs.
+func NewMock() *Mock {
+	return &Mock{
+		queues:   make(map[string][]Result),
+		tasks:    make(map[string]Result),
+		uploads:  make(map[string]string),
+		canceled: make(map[string]bool),
+		Default:  Result{Outputs: []any{}},
+	}
+}
+
+// Enqueue scripts the results of the next predictions for model, in order.
+// Once the queue is drained, Default is used.
+func (m *Mock) Enqueue(model string, results ...Result) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.queues[model] = append(m.queues[model], results...)
+}
+
+// SetUpload scripts the URL returned when file is uploaded.
+func (m *Mock) SetUpload(file, url string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.uploads[file] = url
+}
+
+// Calls returns a copy of all recorded calls in invocation order.
+func (m *Mock) Calls() []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	calls := make([]Call, len(m.calls))
+	copy(calls, m.calls)
+	return calls
+}
+
+// CallsTo returns the recorded calls of a single method, e.g. "Run".
+func (m *Mock) CallsTo(method string) []Call {
+	var calls []Call
+	for _, call := range m.Calls() {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// Reset clears recorded calls, queued results and known tasks.
+func (m *Mock) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = nil
+	m.queues = make(map[string][]Result)
+	m.tasks = make(map[string]Result)
+	m.canceled = make(map[string]bool)
+	m.nextID = 0
+}
+
+func (m *Mock) record(call Call) {
+	m.calls = append(m.calls, call)
+}
+
+// submit pops the next scripted result for model and registers it as a task.
+func (m *Mock) submit(model string) (string, error) {
+	result := m.Default
+	if queue := m.queues[model]; len(queue) > 0 {
+		result = queue[0]
+		m.queues[model] = queue[1:]
+	}
+	if result.SubmitErr != nil {
+		return "", result.SubmitErr
+	}
+
+	if result.TaskID == "" {
+		m.nextID++
+		result.TaskID = fmt.Sprintf("mock-task-%d", m.nextID)
+	}
+	m.tasks[result.TaskID] = result
+	return result.TaskID, nil
+}
+
+func (m *Mock) wait(taskID string) (map[string]any, error) {
+	result, ok := m.tasks[taskID]
+	if !ok {
+		return nil, fmt.Errorf("failed to get result for task %s: HTTP 404: task not found", taskID)
+	}
+	if m.canceled[taskID] {
+		return nil, fmt.Errorf("prediction failed (task_id: %s): %w", taskID, ErrCanceled)
+	}
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	outputs := result.Outputs
+	if outputs == nil {
+		outputs = []any{}
+	}
+	return map[string]any{"outputs": outputs}, nil
+}
+
+// Run implements api.Runner.
+func (m *Mock) Run(model string, input map[string]any, opts ...api.RunOption) (map[string]any, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	taskID, err := m.submit(model)
+	m.record(Call{Method: "Run", Model: model, Input: input, TaskID: taskID})
+	if err != nil {
+		return nil, err
+	}
+	return m.wait(taskID)
+}
+
+// RunNoThrow implements api.Runner.
+func (m *Mock) RunNoThrow(model string, input map[string]any, opts ...api.RunOption) *api.RunNoThrowResult {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	taskID, err := m.submit(model)
+	m.record(Call{Method: "RunNoThrow", Model: model, Input: input, TaskID: taskID})
+	if err != nil {
+		return &api.RunNoThrowResult{
+			Detail: api.RunDetail{TaskID: "unknown", Status: "failed", Model: model, Error: err.Error()},
+		}
+	}
+
+	output, err := m.wait(taskID)
+	if err != nil {
+		return &api.RunNoThrowResult{
+			Detail: api.RunDetail{TaskID: taskID, Status: "failed", Model: model, Error: err.Error()},
+		}
+	}
+	outputs, _ := output["outputs"].([]any)
+	return &api.RunNoThrowResult{
+		Outputs: outputs,
+		Detail:  api.RunDetail{TaskID: taskID, Status: "completed", Model: model},
+	}
+}
+
+// RunContext implements api.Runner. It fails with ctx.Err() when ctx is
+// already done and otherwise behaves like Run, recording a "Run" call.
+func (m *Mock) RunContext(ctx context.Context, model string, input map[string]any, opts ...api.RunOption) (map[string]any, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return m.Run(model, input, opts...)
+}
+
+// RunNoThrowContext implements api.Runner. It reports ctx.Err() when ctx is
+// already done and otherwise behaves like RunNoThrow, recording a
+// "RunNoThrow" call.
+func (m *Mock) RunNoThrowContext(ctx context.Context, model string, input map[string]any, opts ...api.RunOption) *api.RunNoThrowResult {
+	if err := ctx.Err(); err != nil {
+		return &api.RunNoThrowResult{
+			Detail: api.RunDetail{TaskID: "unknown", Status: "failed", Model: model, Error: err.Error()},
+		}
+	}
+	return m.RunNoThrow(model, input, opts...)
+}
+
+// Upload implements api.Runner. Files without a scripted URL get a fake one.
+func (m *Mock) Upload(file string, opts ...api.UploadOption) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.record(Call{Method: "Upload", File: file})
+	if url, ok := m.uploads[file]; ok {
+		return url, nil
+	}
+	return "https://mock.wavespeed.ai/uploads/" + file, nil
+}
+
+// Submit implements api.Runner.
+func (m *Mock) Submit(model string, input map[string]any, opts ...api.RunOption) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	taskID, err := m.submit(model)
+	m.record(Call{Method: "Submit", Model: model, Input: input, TaskID: taskID})
+	return taskID, err
+}
+
+// Wait implements api.Runner.
+func (m *Mock) Wait(taskID string, opts ...api.RunOption) (map[string]any, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.record(Call{Method: "Wait", TaskID: taskID})
+	return m.wait(taskID)
+}
+
+// GetPrediction implements api.Runner. Scripted tasks are reported as
+// already finished.
+func (m *Mock) GetPrediction(taskID string) (*api.Prediction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.record(Call{Method: "GetPrediction", TaskID: taskID})
+	result, ok := m.tasks[taskID]
+	if !ok {
+		return nil, fmt.Errorf("failed to get result for task %s: HTTP 404: task not found", taskID)
+	}
+
+	pred := &api.Prediction{ID: taskID, Status: "completed", Outputs: result.Outputs}
+	switch {
+	case m.canceled[taskID]:
+		pred.Status = "canceled"
+		pred.Outputs = nil
+	case result.Err != nil:
+		pred.Status = "failed"
+		pred.Error = result.Err.Error()
+		pred.Outputs = nil
+	}
+	return pred, nil
+}
+
+// Cancel implements api.Runner.
+func (m *Mock) Cancel(taskID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.record(Call{Method: "Cancel", TaskID: taskID})
+	if _, ok := m.tasks[taskID]; !ok {
+		return fmt.Errorf("failed to cancel task %s: HTTP 404: task not found", taskID)
+	}
+	m.canceled[taskID] = true
+	return nil
+}
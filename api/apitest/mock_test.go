@@ -0,0 +1,87 @@
+package apitest
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestMockRunReturnsScriptedResults(t *testing.T) {
+	mock := NewMock()
+	mock.Enqueue("model-a",
+		Result{TaskID: "task-1", Outputs: []any{"https://example.com/1.png"}},
+		Result{Err: errors.New("Model error")},
+	)
+
+	output, err := mock.Run("model-a", map[string]any{"prompt": "Cat"})
+	if err != nil {
+		t.Fatalf("run error: %v", err)
+	}
+	if outputs := output["outputs"].([]any); len(outputs) != 1 || outputs[0] != "https://example.com/1.png" {
+		t.Errorf("unexpected outputs: %+v", output)
+	}
+
+	if _, err := mock.Run("model-a", nil); err == nil || err.Error() != "Model error" {
+		t.Errorf("expected scripted error, got %v", err)
+	}
+
+	calls := mock.CallsTo("Run")
+	if len(calls) != 2 {
+		t.Fatalf("expected 2 Run calls, got %d", len(calls))
+	}
+	if calls[0].TaskID != "task-1" || calls[0].Input["prompt"] != "Cat" {
+		t.Errorf("unexpected first call: %+v", calls[0])
+	}
+}
+
+func TestMockSubmitWaitCancel(t *testing.T) {
+	mock := NewMock()
+
+	taskID, err := mock.Submit("model-a", nil)
+	if err != nil {
+		t.Fatalf("submit error: %v", err)
+	}
+	if err := mock.Cancel(taskID); err != nil {
+		t.Fatalf("cancel error: %v", err)
+	}
+	if _, err := mock.Wait(taskID); !errors.Is(err, ErrCanceled) {
+		t.Errorf("expected ErrCanceled, got %v", err)
+	}
+	if err := mock.Cancel("missing"); err == nil {
+		t.Error("expected error canceling unknown task")
+	}
+}
+
+func TestMockRunNoThrow(t *testing.T) {
+	mock := NewMock()
+	mock.Enqueue("model-a", Result{SubmitErr: errors.New("HTTP 500")})
+
+	result := mock.RunNoThrow("model-a", nil)
+	if result.Outputs != nil || result.Detail.Status != "failed" {
+		t.Errorf("expected failed result, got %+v", result)
+	}
+
+	result = mock.RunNoThrow("model-a", nil)
+	if result.Outputs == nil || result.Detail.Status != "completed" || result.Detail.TaskID == "" {
+		t.Errorf("expected completed result, got %+v", result)
+	}
+}
+
+func TestMockRunContext(t *testing.T) {
+	mock := NewMock()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	if _, err := mock.RunContext(ctx, "model-a", nil); err != nil {
+		t.Fatalf("run error: %v", err)
+	}
+	cancel()
+	if _, err := mock.RunContext(ctx, "model-a", nil); err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if result := mock.RunNoThrowContext(ctx, "model-a", nil); result.Outputs != nil || result.Detail.Error != "context canceled" {
+		t.Errorf("expected canceled result, got %+v", result.Detail)
+	}
+	if calls := mock.CallsTo("Run"); len(calls) != 1 {
+		t.Errorf("expected 1 recorded run, got %d", len(calls))
+	}
+}
@@ -0,0 +1,254 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned by Run, RunNoThrow and Submit without contacting
+// the API while the circuit breaker is open. Check for it with errors.Is.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitState is the state of a circuit breaker.
+type CircuitState int
+
+const (
+	// CircuitClosed lets all requests through.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen rejects requests with ErrCircuitOpen.
+	CircuitOpen
+	// CircuitHalfOpen lets a limited number of trial requests through.
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("CircuitState(%d)", int(s))
+}
+
+// CircuitBreakerConfig configures WithCircuitBreaker. Zero values use the
+// documented defaults.
+type CircuitBreakerConfig struct {
+	// FailureThreshold is the number of consecutive failed submissions that
+	// opens the circuit (default 5).
+	FailureThreshold int
+	// OpenTimeout is how long the circuit stays open before letting trial
+	// requests through (default 30 seconds).
+	OpenTimeout time.Duration
+	// HalfOpenMaxCalls is the number of concurrent trial requests allowed
+	// while half-open (default 1).
+	HalfOpenMaxCalls int
+	// PerModel keeps a separate circuit for each model instead of one for
+	// the whole client.
+	PerModel bool
+	// OnStateChange is called after every state transition. model is empty
+	// unless PerModel is set.
+	OnStateChange func(model string, from, to CircuitState)
+}
+
+// WithCircuitBreaker stops submitting tasks while the API is failing.
+//
+// Submission attempts that fail with connection errors, timeouts or HTTP 5xx
+// count as failures, including each connection retry; after FailureThreshold
+// consecutive failures the circuit opens, submissions in progress stop
+// retrying, and new submissions fail fast with ErrCircuitOpen. After OpenTimeout the circuit is
+// half-open: a trial submission closes it on success or reopens it on failure.
+//
+// Example:
+//
+//	client := api.NewClient(api.WithCircuitBreaker(api.CircuitBreakerConfig{
+//	    FailureThreshold: 3,
+//	    OpenTimeout:      time.Minute,
+//	    OnStateChange: func(model string, from, to api.CircuitState) {
+//	        log.Printf("wavespeed circuit %s -> %s", from, to)
+//	    },
+//	}))
+func WithCircuitBreaker(config CircuitBreakerConfig) ClientOption {
+	if config.FailureThreshold <= 0 {
+		config.FailureThreshold = 5
+	}
+	if config.OpenTimeout <= 0 {
+		config.OpenTimeout = 30 * time.Second
+	}
+	if config.HalfOpenMaxCalls <= 0 {
+		config.HalfOpenMaxCalls = 1
+	}
+	return func(c *Client) {
+		c.breaker = &circuitBreaker{config: config, circuits: map[string]*circuit{}}
+	}
+}
+
+// CircuitState returns the breaker state for model (ignored unless the
+// breaker is per model). It is always CircuitClosed without a breaker.
+func (c *Client) CircuitState(model string) CircuitState {
+	if c.breaker == nil {
+		return CircuitClosed
+	}
+	b := c.breaker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.circuit(model).currentState(b.config.OpenTimeout)
+}
+
+type circuit struct {
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	trials   int
+}
+
+// currentState reports an open circuit whose timeout elapsed as half-open.
+func (c *circuit) currentState(openTimeout time.Duration) CircuitState {
+	if c.state == CircuitOpen && time.Since(c.openedAt) >= openTimeout {
+		return CircuitHalfOpen
+	}
+	return c.state
+}
+
+type circuitBreaker struct {
+	config CircuitBreakerConfig
+
+	mu       sync.Mutex
+	circuits map[string]*circuit
+}
+
+type transition struct {
+	model    string
+	from, to CircuitState
+}
+
+func (b *circuitBreaker) circuit(model string) *circuit {
+	if !b.config.PerModel {
+		model = ""
+	}
+	c, ok := b.circuits[model]
+	if !ok {
+		c = &circuit{}
+		b.circuits[model] = c
+	}
+	return c
+}
+
+func (b *circuitBreaker) key(model string) string {
+	if b.config.PerModel {
+		return model
+	}
+	return ""
+}
+
+// allow reports whether a submission for model may proceed.
+func (b *circuitBreaker) allow(model string) error {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	var changes []transition
+	c := b.circuit(model)
+	if c.state == CircuitOpen && c.currentState(b.config.OpenTimeout) == CircuitHalfOpen {
+		changes = append(changes, b.setState(model, c, CircuitHalfOpen))
+	}
+
+	var err error
+	switch c.state {
+	case CircuitOpen:
+		err = ErrCircuitOpen
+	case CircuitHalfOpen:
+		if c.trials >= b.config.HalfOpenMaxCalls {
+			err = ErrCircuitOpen
+		} else {
+			c.trials++
+		}
+	}
+	b.mu.Unlock()
+
+	b.notify(changes)
+	if err != nil && b.config.PerModel {
+		return fmt.Errorf("%w (model: %s)", err, model)
+	}
+	return err
+}
+
+// isOpen reports whether the circuit for model rejects submissions.
+func (b *circuitBreaker) isOpen(model string) bool {
+	if b == nil {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.circuit(model).currentState(b.config.OpenTimeout) == CircuitOpen
+}
+
+// record updates the circuit for model with the outcome of a submission
+// attempt.
+func (b *circuitBreaker) record(model string, err error) {
+	if b == nil || errors.Is(err, ErrCircuitOpen) {
+		return
+	}
+
+	b.mu.Lock()
+	var changes []transition
+	c := b.circuit(model)
+	if c.state == CircuitHalfOpen && c.trials > 0 {
+		c.trials--
+	}
+
+	if isOutageError(err) {
+		c.failures++
+		if c.state == CircuitHalfOpen || (c.state == CircuitClosed && c.failures >= b.config.FailureThreshold) {
+			c.openedAt = time.Now()
+			changes = append(changes, b.setState(model, c, CircuitOpen))
+		}
+	} else {
+		c.failures = 0
+		if c.state == CircuitHalfOpen {
+			changes = append(changes, b.setState(model, c, CircuitClosed))
+		}
+	}
+	b.mu.Unlock()
+
+	b.notify(changes)
+}
+
+func (b *circuitBreaker) setState(model string, c *circuit, state CircuitState) transition {
+	t := transition{model: b.key(model), from: c.state, to: state}
+	c.state = state
+	c.trials = 0
+	if state == CircuitClosed {
+		c.failures = 0
+	}
+	return t
+}
+
+// notify runs the state change callback outside the lock so it may call back
+// into the client.
+func (b *circuitBreaker) notify(changes []transition) {
+	if b.config.OnStateChange == nil {
+		return
+	}
+	for _, t := range changes {
+		b.config.OnStateChange(t.model, t.from, t.to)
+	}
+}
+
+// isOutageError reports whether err indicates the API itself is unhealthy,
+// as opposed to a rejected request or rate limiting.
+func isOutageError(err error) bool {
+	if err == nil {
+		return false
+	}
+	errStr := strings.ToLower(err.Error())
+	return strings.Contains(errStr, "timeout") ||
+		strings.Contains(errStr, "connection") ||
+		strings.Contains(errStr, "http 5")
+}
@@ -0,0 +1,219 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerOpensAndFailsFast(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte("Bad Gateway"))
+	}))
+	defer server.Close()
+
+	var changes []string
+	client := NewClient(
+		WithAPIKey("test-key"),
+		WithBaseURL(server.URL),
+		WithClientMaxRetries(0),
+		WithMaxConnectionRetries(0),
+		WithRetryInterval(0.01),
+		WithCircuitBreaker(CircuitBreakerConfig{
+			FailureThreshold: 2,
+			OpenTimeout:      time.Hour,
+			OnStateChange: func(model string, from, to CircuitState) {
+				changes = append(changes, from.String()+"->"+to.String())
+			},
+		}),
+	)
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.Submit("wavespeed-ai/z-image/turbo", nil); err == nil || errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("expected HTTP error on attempt %d, got %v", i, err)
+		}
+	}
+	if state := client.CircuitState(""); state != CircuitOpen {
+		t.Fatalf("expected open circuit, got %s", state)
+	}
+
+	before := atomic.LoadInt32(&hits)
+	_, err := client.Run("wavespeed-ai/z-image/turbo", nil, WithMaxRetries(3))
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen, got %v", err)
+	}
+	if _, err := client.Submit("wavespeed-ai/z-image/turbo", nil); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected ErrCircuitOpen from Submit, got %v", err)
+	}
+	if got := atomic.LoadInt32(&hits); got != before {
+		t.Errorf("expected no requests while open, got %d", got-before)
+	}
+
+	detail := client.RunNoThrow("wavespeed-ai/z-image/turbo", nil)
+	if detail.Outputs != nil || !strings.Contains(detail.Detail.Error, "circuit breaker is open") {
+		t.Errorf("unexpected RunNoThrow detail: %+v", detail.Detail)
+	}
+
+	if len(changes) != 1 || changes[0] != "closed->open" {
+		t.Errorf("unexpected state changes: %v", changes)
+	}
+}
+
+func TestCircuitBreakerStopsConnectionRetries(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	client := NewClient(
+		WithAPIKey("test-key"),
+		WithBaseURL(url),
+		WithMaxConnectionRetries(5),
+		WithRetryInterval(10),
+		WithLogOutput(io.Discard),
+		WithCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 1, OpenTimeout: time.Hour}),
+	)
+
+	start := time.Now()
+	_, err := client.Submit("wavespeed-ai/z-image/turbo", nil)
+	if !errors.Is(err, ErrCircuitOpen) || !strings.Contains(err.Error(), "connection refused") {
+		t.Fatalf("expected ErrCircuitOpen with the connection error, got %v", err)
+	}
+	// No retry delay, let alone the full retry ladder.
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected retries to stop once the circuit opened, took %v", elapsed)
+	}
+}
+
+func TestCircuitBreakerStopsTaskRetries(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	var log bytes.Buffer
+	client := NewClient(
+		WithAPIKey("test-key"),
+		WithBaseURL(url),
+		WithMaxConnectionRetries(1),
+		WithRetryInterval(10),
+		WithLogOutput(&log),
+		WithCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 1, OpenTimeout: time.Hour}),
+	)
+
+	start := time.Now()
+	_, err := client.Run("wavespeed-ai/z-image/turbo", nil, WithMaxRetries(3))
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen, got %v", err)
+	}
+	result := client.RunNoThrow("wavespeed-ai/z-image/turbo", nil, WithMaxRetries(3))
+	if result.Outputs != nil || !strings.Contains(result.Detail.Error, "circuit breaker is open") {
+		t.Errorf("unexpected RunNoThrow detail: %+v", result.Detail)
+	}
+	if strings.Contains(log.String(), "Task attempt") {
+		t.Errorf("expected no task-level retries of an open circuit, got %q", log.String())
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected an open circuit to fail fast, took %v", elapsed)
+	}
+}
+
+func TestCircuitBreakerHalfOpenRecovery(t *testing.T) {
+	var failing atomic.Bool
+	failing.Store(true)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"code":200,"data":{"id":"req-123"}}`))
+	}))
+	defer server.Close()
+
+	var changes []string
+	client := NewClient(
+		WithAPIKey("test-key"),
+		WithBaseURL(server.URL),
+		WithClientMaxRetries(0),
+		WithMaxConnectionRetries(0),
+		WithRetryInterval(0.01),
+		WithCircuitBreaker(CircuitBreakerConfig{
+			FailureThreshold: 1,
+			OpenTimeout:      20 * time.Millisecond,
+			OnStateChange: func(model string, from, to CircuitState) {
+				changes = append(changes, from.String()+"->"+to.String())
+			},
+		}),
+	)
+
+	client.Submit("wavespeed-ai/z-image/turbo", nil)
+	time.Sleep(30 * time.Millisecond)
+	if state := client.CircuitState(""); state != CircuitHalfOpen {
+		t.Fatalf("expected half-open circuit, got %s", state)
+	}
+
+	// A failed trial reopens the circuit.
+	if _, err := client.Submit("wavespeed-ai/z-image/turbo", nil); err == nil || errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected trial request to reach the server, got %v", err)
+	}
+	if state := client.CircuitState(""); state != CircuitOpen {
+		t.Fatalf("expected reopened circuit, got %s", state)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	failing.Store(false)
+	if _, err := client.Submit("wavespeed-ai/z-image/turbo", nil); err != nil {
+		t.Fatalf("submit error: %v", err)
+	}
+	if state := client.CircuitState(""); state != CircuitClosed {
+		t.Errorf("expected closed circuit, got %s", state)
+	}
+
+	want := []string{"closed->open", "open->half-open", "half-open->open", "open->half-open", "half-open->closed"}
+	if strings.Join(changes, ",") != strings.Join(want, ",") {
+		t.Errorf("state changes = %v, want %v", changes, want)
+	}
+}
+
+func TestCircuitBreakerPerModelIgnoresClientErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/broken") {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("invalid input"))
+	}))
+	defer server.Close()
+
+	client := NewClient(
+		WithAPIKey("test-key"),
+		WithBaseURL(server.URL),
+		WithClientMaxRetries(0),
+		WithMaxConnectionRetries(0),
+		WithRetryInterval(0.01),
+		WithCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 1, OpenTimeout: time.Hour, PerModel: true}),
+	)
+
+	client.Submit("acme/broken", nil)
+	for i := 0; i < 3; i++ {
+		client.Submit("acme/picky", nil)
+	}
+
+	if state := client.CircuitState("acme/broken"); state != CircuitOpen {
+		t.Errorf("expected open circuit for broken model, got %s", state)
+	}
+	if state := client.CircuitState("acme/picky"); state != CircuitClosed {
+		t.Errorf("expected HTTP 400 not to open the circuit, got %s", state)
+	}
+	if _, err := client.Submit("acme/broken", nil); !errors.Is(err, ErrCircuitOpen) || !strings.Contains(err.Error(), "acme/broken") {
+		t.Errorf("expected ErrCircuitOpen naming the model, got %v", err)
+	}
+}
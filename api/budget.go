@@ -0,0 +1,195 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// ErrBudgetExceeded is returned by Run, RunNoThrow and Submit when a task
+// would take the spend of the client's Budget over its limit. Check for it
+// with errors.Is.
+var ErrBudgetExceeded = errors.New("budget exceeded")
+
+// EstimateCost returns the expected price of running model with input, in
+// the currency of the model's pricing in the catalog.
+//
+// The price is the model's base price, multiplied by the input field named
+// in Pricing.ScaleBy, such as "num_images" or "duration", when it has one.
+// A missing field counts as its schema default, or 1.
+//
+// Example:
+//
+//	cost, err := client.EstimateCost(ctx, "wavespeed-ai/wan-2.1/t2v-480p", map[string]any{"prompt": "Cat", "duration": 10})
+//	fmt.Printf("%.2f\n", cost)
+func (c *Client) EstimateCost(ctx context.Context, model string, input map[string]any) (float64, error) {
+	m, err := c.GetModel(ctx, model)
+	if err != nil {
+		return 0, fmt.Errorf("failed to estimate cost: %w", err)
+	}
+	return m.Pricing.BasePrice * scaleQuantity(m, input), nil
+}
+
+// scaleQuantity returns the number of pricing units input asks for.
+func scaleQuantity(m *Model, input map[string]any) float64 {
+	field := m.Pricing.ScaleBy
+	if field == "" {
+		return 1
+	}
+	if q, ok := toFloat(input[field]); ok {
+		return q
+	}
+	if m.InputSchema != nil {
+		if prop := m.InputSchema.Properties[field]; prop != nil {
+			if q, ok := toFloat(prop.Default); ok {
+				return q
+			}
+		}
+	}
+	return 1
+}
+
+// toFloat converts a JSON or Go number to float64.
+func toFloat(v any) (float64, bool) {
+	if n, ok := v.(json.Number); ok {
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(rv.Uint()), true
+	}
+	return 0, false
+}
+
+// Budget caps the estimated spend of a client's tasks over a sliding time
+// window. Attach it with WithBudget; once the cap would be passed, new tasks
+// fail with ErrBudgetExceeded until older spend leaves the window. A Budget
+// may be shared by several clients and is safe for concurrent use.
+type Budget struct {
+	limit  float64
+	window time.Duration
+
+	mu      sync.Mutex
+	charges []charge
+	now     func() time.Time
+}
+
+type charge struct {
+	at     time.Time
+	amount float64
+}
+
+// NewBudget creates a budget allowing limit to be spent per window. A zero
+// window caps the total spend instead.
+func NewBudget(limit float64, window time.Duration) *Budget {
+	return &Budget{limit: limit, window: window, now: time.Now}
+}
+
+// WithBudget makes the client estimate the cost of every task it submits
+// and refuse tasks that would exceed budget. Tasks reused through an
+// idempotency key are not charged again.
+//
+// The budget fails closed: a task whose cost cannot be estimated, because the
+// model catalog cannot be fetched or does not list the model, is refused with
+// the estimation error rather than ErrBudgetExceeded, and is not submitted.
+//
+// Example:
+//
+//	budget := api.NewBudget(50, 24*time.Hour) // $50 a day
+//	client := api.NewClient(api.WithBudget(budget))
+//
+//	_, err := client.Run(model, input)
+//	if errors.Is(err, api.ErrBudgetExceeded) {
+//	    log.Println("daily budget reached")
+//	}
+func WithBudget(budget *Budget) ClientOption {
+	return func(c *Client) {
+		c.budget = budget
+	}
+}
+
+// Spent returns the spend within the current window.
+func (b *Budget) Spent() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.spent(b.now())
+}
+
+// Remaining returns how much can still be spent within the current window.
+func (b *Budget) Remaining() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	remaining := b.limit - b.spent(b.now())
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// spent drops charges outside the window and sums the rest.
+func (b *Budget) spent(now time.Time) float64 {
+	if b.window > 0 {
+		cutoff := now.Add(-b.window)
+		i := 0
+		for i < len(b.charges) && !b.charges[i].at.After(cutoff) {
+			i++
+		}
+		b.charges = b.charges[i:]
+	}
+	total := 0.0
+	for _, ch := range b.charges {
+		total += ch.amount
+	}
+	return total
+}
+
+// reserve charges amount, or fails with ErrBudgetExceeded if that would take
+// the spend over the limit. It returns a function that refunds the charge.
+func (b *Budget) reserve(amount float64) (func(), error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	spent := b.spent(now)
+	if spent+amount > b.limit {
+		return nil, fmt.Errorf("%w: spent %.4g of %.4g, task would cost %.4g", ErrBudgetExceeded, spent, b.limit, amount)
+	}
+	ch := charge{at: now, amount: amount}
+	b.charges = append(b.charges, ch)
+
+	refund := func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		for i := len(b.charges) - 1; i >= 0; i-- {
+			if b.charges[i] == ch {
+				b.charges = append(b.charges[:i], b.charges[i+1:]...)
+				return
+			}
+		}
+	}
+	return refund, nil
+}
+
+// chargeBudget reserves the estimated cost of a task on the client's budget.
+// The returned function refunds it if the task could not be submitted. ctx
+// bounds the catalog lookup.
+func (c *Client) chargeBudget(ctx context.Context, model string, input map[string]any) (func(), error) {
+	if c.budget == nil {
+		return func() {}, nil
+	}
+	cost, err := c.EstimateCost(ctx, model, input)
+	if err != nil {
+		return nil, err
+	}
+	return c.budget.reserve(cost)
+}
@@ -0,0 +1,200 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEstimateCost(t *testing.T) {
+	server := newCatalogServer(t, nil)
+	client := NewClient(WithAPIKey("test-key"), WithBaseURL(server.URL))
+
+	tests := []struct {
+		model string
+		input map[string]any
+		want  float64
+	}{
+		{"wavespeed-ai/z-image/turbo", map[string]any{"prompt": "Cat"}, 0.005},
+		{"wavespeed-ai/z-image/turbo", map[string]any{"prompt": "Cat", "num_images": 4}, 0.02},
+		{"wavespeed-ai/wan-2.1/t2v-480p", map[string]any{"prompt": "Cat"}, 0.25},
+		{"wavespeed-ai/wan-2.1/t2v-480p", map[string]any{"prompt": "Cat", "duration": 10.0}, 0.5},
+	}
+	for _, tt := range tests {
+		cost, err := client.EstimateCost(context.Background(), tt.model, tt.input)
+		if err != nil {
+			t.Fatalf("EstimateCost(%s) error: %v", tt.model, err)
+		}
+		if math.Abs(cost-tt.want) > 1e-9 {
+			t.Errorf("EstimateCost(%s, %v) = %v, want %v", tt.model, tt.input, cost, tt.want)
+		}
+	}
+
+	if _, err := client.EstimateCost(context.Background(), "unknown/model", nil); err == nil || !strings.Contains(err.Error(), "model not found") {
+		t.Errorf("expected model not found error, got %v", err)
+	}
+}
+
+func TestBudgetWindow(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	budget := NewBudget(1, time.Hour)
+	budget.now = func() time.Time { return now }
+
+	if _, err := budget.reserve(0.6); err != nil {
+		t.Fatalf("reserve error: %v", err)
+	}
+	now = now.Add(30 * time.Minute)
+	refund, err := budget.reserve(0.4)
+	if err != nil {
+		t.Fatalf("reserve error: %v", err)
+	}
+	if _, err := budget.reserve(0.1); !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("expected ErrBudgetExceeded, got %v", err)
+	}
+
+	refund()
+	if spent := budget.Spent(); math.Abs(spent-0.6) > 1e-9 {
+		t.Errorf("expected 0.6 spent after refund, got %v", spent)
+	}
+
+	// The first charge leaves the window.
+	now = now.Add(31 * time.Minute)
+	if spent := budget.Spent(); spent != 0 {
+		t.Errorf("expected nothing spent in window, got %v", spent)
+	}
+	if remaining := budget.Remaining(); remaining != 1 {
+		t.Errorf("expected full budget remaining, got %v", remaining)
+	}
+
+	// A zero window never forgets.
+	total := NewBudget(1, 0)
+	total.now = func() time.Time { return now }
+	total.reserve(1)
+	now = now.Add(24 * 365 * time.Hour)
+	if _, err := total.reserve(0.01); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("expected ErrBudgetExceeded, got %v", err)
+	}
+}
+
+func TestRunRefusedOverBudget(t *testing.T) {
+	submissions := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/models", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(testCatalog))
+	})
+	mux.HandleFunc("/api/v3/wavespeed-ai/z-image/turbo", func(w http.ResponseWriter, r *http.Request) {
+		submissions++
+		if submissions == 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":400,"message":"bad input"}`))
+			return
+		}
+		w.Write([]byte(`{"code":200,"data":{"id":"req-1","status":"completed","outputs":["https://example.com/cat.png"]}}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	budget := NewBudget(0.01, time.Hour)
+	client := NewClient(WithAPIKey("test-key"), WithBaseURL(server.URL), WithBudget(budget))
+	input := map[string]any{"prompt": "Cat", "num_images": 2}
+
+	// A rejected submission is refunded.
+	if _, err := client.Run("wavespeed-ai/z-image/turbo", input, WithSyncMode(true)); err == nil {
+		t.Fatal("expected first submission to fail")
+	}
+	if spent := budget.Spent(); spent != 0 {
+		t.Errorf("expected failed submission to be refunded, got %v spent", spent)
+	}
+
+	if _, err := client.Run("wavespeed-ai/z-image/turbo", input, WithSyncMode(true)); err != nil {
+		t.Fatalf("run error: %v", err)
+	}
+	if _, err := client.Run("wavespeed-ai/z-image/turbo", input, WithSyncMode(true)); !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("expected ErrBudgetExceeded, got %v", err)
+	}
+	if _, err := client.Submit("wavespeed-ai/z-image/turbo", input); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("expected Submit to be refused, got %v", err)
+	}
+	result := client.RunNoThrow("wavespeed-ai/z-image/turbo", input, WithSyncMode(true))
+	if result.Outputs != nil || !strings.Contains(result.Detail.Error, "budget exceeded") {
+		t.Errorf("expected RunNoThrow to report the budget, got %+v", result.Detail)
+	}
+	if submissions != 2 {
+		t.Errorf("expected 2 submissions, got %d", submissions)
+	}
+
+	// Without a catalog entry the cost is unknown and the task is refused.
+	_, err := client.Run("unknown/model", input)
+	if err == nil || !strings.Contains(err.Error(), "failed to estimate cost") || errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("expected estimate error, got %v", err)
+	}
+}
+
+func TestBudgetFailsClosedWithoutCatalog(t *testing.T) {
+	submissions := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/models", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte("catalog unavailable"))
+	})
+	mux.HandleFunc("/api/v3/wavespeed-ai/z-image/turbo", func(w http.ResponseWriter, r *http.Request) {
+		submissions++
+		w.Write([]byte(`{"code":200,"data":{"id":"req-1"}}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	budget := NewBudget(100, 0)
+	client := NewClient(WithAPIKey("test-key"), WithBaseURL(server.URL), WithBudget(budget))
+	_, err := client.Submit("wavespeed-ai/z-image/turbo", map[string]any{"prompt": "Cat"})
+	if err == nil || !strings.Contains(err.Error(), "HTTP 503") || errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("expected the catalog error, got %v", err)
+	}
+	if submissions != 0 || budget.Spent() != 0 {
+		t.Errorf("expected nothing submitted or charged, got %d submissions, %v spent", submissions, budget.Spent())
+	}
+}
+
+func TestToFloat(t *testing.T) {
+	values := []any{
+		2.0, float32(2), 2, int8(2), int16(2), int32(2), int64(2),
+		uint(2), uint8(2), uint16(2), uint32(2), uint64(2), json.Number("2"),
+	}
+	for _, v := range values {
+		if f, ok := toFloat(v); !ok || f != 2 {
+			t.Errorf("toFloat(%T) = %v, %v; want 2, true", v, f, ok)
+		}
+	}
+	for _, v := range []any{nil, "2", json.Number("x"), true} {
+		if _, ok := toFloat(v); ok {
+			t.Errorf("toFloat(%#v): expected not a number", v)
+		}
+	}
+}
+
+func TestBudgetCatalogLookupRespectsContext(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.Write([]byte(testCatalog))
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := NewClient(WithAPIKey("test-key"), WithBaseURL(server.URL), WithBudget(NewBudget(100, 0)))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := client.RunContext(ctx, "wavespeed-ai/z-image/turbo", map[string]any{"prompt": "Cat"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the pricing lookup to stop at the deadline, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected the budget to honor the deadline, took %v", elapsed)
+	}
+}
@@ -7,11 +7,13 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"mime/multipart"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -29,6 +31,7 @@ func WithAPIKey(apiKey string) ClientOption {
 func WithBaseURL(baseURL string) ClientOption {
 	return func(c *Client) {
 		c.baseURL = baseURL
+		c.endpoints = nil
 	}
 }
 
@@ -60,6 +63,14 @@ func WithRetryInterval(interval float64) ClientOption {
 	}
 }
 
+// WithLogOutput sets where the client writes retry, failover and task journal
+// messages. They go to os.Stderr by default; use io.Discard to silence them.
+func WithLogOutput(w io.Writer) ClientOption {
+	return func(c *Client) {
+		c.logOutput = w
+	}
+}
+
 // RunOption is a function that configures RunOptions.
 type RunOption func(*RunOptions)
 
@@ -69,6 +80,14 @@ type RunOptions struct {
 	PollInterval   float64
 	EnableSyncMode bool
 	MaxRetries     int
+	PollStrategy   PollStrategy
+	PollTimeout    float64
+	SyncFallback   bool
+	IdempotencyKey string
+	Validate       bool
+	FallbackModels []string
+	InputAdapters  map[string]InputAdapter
+	RoutingKey     string
 }
 
 // WithTimeout sets the maximum time to wait for completion.
@@ -92,6 +111,15 @@ func WithSyncMode(enable bool) RunOption {
 	}
 }
 
+// WithSyncFallback makes a sync mode run that hits the server-side sync
+// timeout (code 5004) keep polling the task until the overall Timeout instead
+// of returning an error. Sync mode then only lowers latency.
+func WithSyncFallback() RunOption {
+	return func(o *RunOptions) {
+		o.SyncFallback = true
+	}
+}
+
 // WithMaxRetries sets the maximum number of task-level retries.
 func WithMaxRetries(retries int) RunOption {
 	return func(o *RunOptions) {
@@ -122,6 +150,25 @@ type Client struct {
 	maxRetries           int
 	maxConnectionRetries int
 	retryInterval        float64
+	credentials          CredentialsProvider
+	keys                 *keyPool
+	endpoints            *endpointSet
+	breaker              *circuitBreaker
+	store                TaskStore
+	resumeConcurrency    int
+	catalog              modelCatalog
+	budget               *Budget
+	logOutput            io.Writer
+
+	routesMu   sync.Mutex
+	routes     map[string]*taskRoute
+	idempotent map[string]*idempotentEntry
+	// idempotentSwept is when expired idempotency keys were last dropped.
+	idempotentSwept time.Time
+
+	// configErr records a failure to load the config file in WithProfile;
+	// it is returned by the first request.
+	configErr error
 }
 
 // ClientOptions configures the client at initialization time.
@@ -134,7 +181,8 @@ type ClientOptions struct {
 	RetryInterval        float64
 }
 
-type prediction struct {
+// Prediction is the state of a task as reported by the API.
+type Prediction struct {
 	ID        string            `json:"id"`
 	Model     string            `json:"model"`
 	Status    string            `json:"status"`
@@ -144,12 +192,57 @@ type prediction struct {
 	Code      int               `json:"code"`
 	CreatedAt string            `json:"created_at"`
 	URLs      map[string]string `json:"urls"`
+	// Variant is the Router variant that ran the prediction, if any.
+	Variant string `json:"variant,omitempty"`
+
+	// raw holds the outputs of a run as returned by the API, which need not
+	// be a list.
+	raw any
+}
+
+// UnmarshalJSON decodes a prediction from the API. Outputs that are a single
+// value rather than a list are kept as returned and wrapped in a list.
+func (p *Prediction) UnmarshalJSON(data []byte) error {
+	type prediction Prediction
+	aux := struct {
+		*prediction
+		Outputs any `json:"outputs"`
+	}{prediction: (*prediction)(p)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	p.raw = aux.Outputs
+	p.Outputs = nil
+	if aux.Outputs != nil {
+		p.Outputs = outputList(aux.Outputs)
+	}
+	return nil
+}
+
+// rawOutputs returns the outputs as returned by the API.
+func (p *Prediction) rawOutputs() any {
+	if p.raw != nil {
+		return p.raw
+	}
+	return p.Outputs
+}
+
+// outputList returns task outputs as a list. Models returning a single value
+// that is not a list, such as the text of an LLM, get a one-element list.
+func outputList(outputs any) []any {
+	switch v := outputs.(type) {
+	case nil:
+		return []any{}
+	case []any:
+		return v
+	}
+	return []any{outputs}
 }
 
 type predictionResponse struct {
 	Code    int        `json:"code"`
 	Message string     `json:"message"`
-	Data    prediction `json:"data"`
+	Data    Prediction `json:"data"`
 }
 
 type uploadResponse struct {
@@ -169,6 +262,11 @@ type uploadResponse struct {
 //   - maxConnectionRetries: 5
 //   - retryInterval: 1.0 second
 //
+// The defaults can be overridden with the WAVESPEED_BASE_URL,
+// WAVESPEED_CONNECTION_TIMEOUT, WAVESPEED_MAX_RETRIES,
+// WAVESPEED_MAX_CONNECTION_RETRIES and WAVESPEED_RETRY_INTERVAL environment
+// variables, or loaded from a config file with WithProfile.
+//
 // Example:
 //
 //	// With defaults (API key from environment)
@@ -186,13 +284,15 @@ type uploadResponse struct {
 func NewClient(opts ...ClientOption) *Client {
 	// Create client with default values
 	client := &Client{
-		apiKey:               os.Getenv("WAVESPEED_API_KEY"),
 		baseURL:              "https://api.wavespeed.ai",
 		connectionTimeout:    10.0,
 		maxRetries:           0,
 		maxConnectionRetries: 5,
 		retryInterval:        1.0,
+		catalog:              modelCatalog{ttl: 10 * time.Minute},
+		logOutput:            os.Stderr,
 	}
+	applyEnv(client)
 
 	// Apply user-provided options
 	for _, opt := range opts {
@@ -206,17 +306,42 @@ func NewClient(opts ...ClientOption) *Client {
 }
 
 func (c *Client) getHeaders() (map[string]string, error) {
-	if c.apiKey == "" {
-		return nil, errors.New("API key is required. Set WAVESPEED_API_KEY environment variable or pass api_key to Client()")
+	apiKey, err := c.resolveAPIKey()
+	if err != nil {
+		return nil, err
 	}
 	return map[string]string{
 		"Content-Type":  "application/json",
-		"Authorization": "Bearer " + c.apiKey,
+		"Authorization": "Bearer " + apiKey,
 	}, nil
 }
 
-func (c *Client) submit(model string, input map[string]any, enableSyncMode bool, timeout float64) (string, map[string]any, error) {
-	url := c.baseURL + "/api/v3/" + model
+// submit submits a task, unless idempotencyKey already submitted one. ctx
+// bounds the wait for a submission in progress with the same key and the
+// catalog lookup of the budget.
+func (c *Client) submit(ctx context.Context, model string, input map[string]any, enableSyncMode bool, timeout float64, idempotencyKey string) (string, map[string]any, error) {
+	if idempotencyKey != "" {
+		taskID, release, err := c.reserveIdempotent(ctx, idempotencyKey)
+		if err != nil {
+			return "", nil, err
+		}
+		if taskID != "" {
+			return taskID, nil, nil
+		}
+		defer release()
+	}
+	refund, err := c.chargeBudget(ctx, model, input)
+	if err != nil {
+		return "", nil, err
+	}
+	requestID, result, err := c.submitRequest(model, input, enableSyncMode, timeout, idempotencyKey)
+	if err != nil {
+		refund()
+	}
+	return requestID, result, err
+}
+
+func (c *Client) submitRequest(model string, input map[string]any, enableSyncMode bool, timeout float64, idempotencyKey string) (string, map[string]any, error) {
 	body := make(map[string]any)
 	if input != nil {
 		for k, v := range input {
@@ -242,46 +367,99 @@ func (c *Client) submit(model string, input map[string]any, enableSyncMode bool,
 		return "", nil, err
 	}
 
+	// The key stays in flight until the task finishes if it is submitted asynchronously.
+	apiKey := c.keys.acquire()
+	bound := false
+	defer func() {
+		if !bound {
+			c.keys.release(apiKey)
+		}
+	}()
+
+	// Each endpoint gets maxConnectionRetries+1 attempts, tried in rotation.
+	endpoints := c.endpointOrder()
+	attempts := (c.maxConnectionRetries + 1) * len(endpoints)
+
 	var lastErr error
-	for retry := 0; retry <= c.maxConnectionRetries; retry++ {
+	for retry := 0; retry < attempts; retry++ {
+		baseURL := endpoints[retry%len(endpoints)]
+		failover := len(endpoints) > 1 && (retry+1)%len(endpoints) != 0
+
 		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(requestTimeout*float64(time.Second)))
 		defer cancel()
 
-		req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(bodyBytes))
+		req, err := http.NewRequestWithContext(ctx, "POST", baseURL+"/api/v3/"+model, bytes.NewReader(bodyBytes))
 		if err != nil {
 			return "", nil, err
 		}
 
-		headers, err := c.getHeaders()
+		headers, err := c.headersWithKey(apiKey)
 		if err != nil {
 			return "", nil, err
 		}
 		for k, v := range headers {
 			req.Header.Set(k, v)
 		}
+		if idempotencyKey != "" {
+			req.Header.Set("Idempotency-Key", idempotencyKey)
+		}
 
 		client := &http.Client{
 			Timeout: time.Duration(connectTimeout * float64(time.Second)),
 		}
-		resp, err := client.Do(req)
+		// The breaker sees every attempt, so retries stop once it opens.
+		if err := c.breaker.allow(model); err != nil {
+			if lastErr != nil {
+				return "", nil, fmt.Errorf("%w (last error: %v)", err, lastErr)
+			}
+			return "", nil, err
+		}
+		resp, err := c.do(client, req)
 		if err != nil {
 			lastErr = err
-			if retry < c.maxConnectionRetries {
-				delay := c.retryInterval * float64(retry+1)
-				fmt.Printf("Connection error on attempt %d/%d:\n", retry+1, c.maxConnectionRetries+1)
-				fmt.Printf("%v\n", err)
-				fmt.Printf("Retrying in %.1f seconds...\n", delay)
+			c.breaker.record(model, err)
+			c.endpoints.failure(baseURL, err)
+			if failover {
+				c.logf("Connection error on %s: %v\n", baseURL, err)
+				c.logf("Failing over to %s...\n", endpoints[(retry+1)%len(endpoints)])
+				continue
+			}
+			if retry < attempts-1 {
+				if c.breaker.isOpen(model) {
+					continue
+				}
+				round := retry / len(endpoints)
+				delay := c.retryInterval * float64(round+1)
+				c.logf("Connection error on attempt %d/%d:\n", round+1, c.maxConnectionRetries+1)
+				c.logf("%v\n", err)
+				c.logf("Retrying in %.1f seconds...\n", delay)
 				time.Sleep(time.Duration(delay * float64(time.Second)))
 				continue
 			}
-			return "", nil, fmt.Errorf("failed to submit prediction after %d attempts: %w", c.maxConnectionRetries+1, lastErr)
+			return "", nil, fmt.Errorf("failed to submit prediction after %d attempts: %w", attempts, lastErr)
 		}
 		defer resp.Body.Close()
 
 		if resp.StatusCode != 200 {
+			if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusUnauthorized {
+				c.keys.penalize(apiKey)
+			}
 			bodyText, _ := io.ReadAll(resp.Body)
-			return "", nil, fmt.Errorf("failed to submit prediction: HTTP %d: %s", resp.StatusCode, string(bodyText))
+			err := &statusError{code: resp.StatusCode, msg: fmt.Sprintf("failed to submit prediction: HTTP %d: %s", resp.StatusCode, string(bodyText))}
+			c.breaker.record(model, err)
+			// Server errors fail over once through the other endpoints.
+			if resp.StatusCode >= 500 {
+				c.endpoints.failure(baseURL, err)
+				if failover && retry < len(endpoints)-1 {
+					c.logf("%v\n", err)
+					c.logf("Failing over to %s...\n", endpoints[retry+1])
+					continue
+				}
+			}
+			return "", nil, err
 		}
+		c.breaker.record(model, nil)
+		c.endpoints.success(baseURL)
 
 		var result predictionResponse
 		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
@@ -289,12 +467,21 @@ func (c *Client) submit(model string, input map[string]any, enableSyncMode bool,
 		}
 
 		if enableSyncMode {
+			c.rememberIdempotent(idempotencyKey, result.Data.ID)
+			// A task still running after a sync timeout keeps its route so it
+			// can be polled later, but its key no longer counts as in flight.
+			if id := result.Data.ID; id != "" && result.Data.Status != "completed" && result.Data.Status != "failed" {
+				c.bindTask(id, apiKey, baseURL)
+				c.finishTask(id, false)
+				bound = true
+				c.recordSubmission(id, model, input, idempotencyKey, apiKey)
+			}
 			return "", map[string]any{
 				"data": map[string]any{
 					"id":         result.Data.ID,
 					"status":     result.Data.Status,
 					"error":      result.Data.Error,
-					"outputs":    result.Data.Outputs,
+					"outputs":    result.Data.rawOutputs(),
 					"code":       result.Data.Code,
 					"created_at": result.Data.CreatedAt,
 					"urls":       result.Data.URLs,
@@ -307,119 +494,194 @@ func (c *Client) submit(model string, input map[string]any, enableSyncMode bool,
 			return "", nil, fmt.Errorf("no request ID in response: %v", result)
 		}
 
+		c.bindTask(requestID, apiKey, baseURL)
+		bound = apiKey != ""
+		c.recordSubmission(requestID, model, input, idempotencyKey, apiKey)
+		c.rememberIdempotent(idempotencyKey, requestID)
 		return requestID, nil, nil
 	}
 
-	return "", nil, fmt.Errorf("failed to submit prediction after %d attempts: %w", c.maxConnectionRetries+1, lastErr)
+	return "", nil, fmt.Errorf("failed to submit prediction after %d attempts: %w", attempts, lastErr)
 }
 
-func (c *Client) getResult(requestID string, timeout float64) (map[string]any, error) {
-	url := c.baseURL + "/api/v3/predictions/" + requestID + "/result"
-	requestTimeout := timeout
-	if requestTimeout == 0 {
-		requestTimeout = 36000.0
-	}
-
-	connectTimeout := c.connectionTimeout
-	if requestTimeout > 0 && connectTimeout > requestTimeout {
-		connectTimeout = requestTimeout
-	}
+// pollResult fetches the current result of a task, giving each HTTP request
+// at most requestTimeout. It also returns the delay suggested by the server
+// through a Retry-After header, if any. An HTTP 429 response is returned as
+// an error together with its hint.
+func (c *Client) pollResult(ctx context.Context, requestID string, requestTimeout time.Duration) (map[string]any, time.Duration, error) {
+	url := c.taskBaseURL(requestID) + "/api/v3/predictions/" + requestID + "/result"
 
 	var lastErr error
 	for retry := 0; retry <= c.maxConnectionRetries; retry++ {
-		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(requestTimeout*float64(time.Second)))
+		reqCtx, cancel := context.WithTimeout(ctx, requestTimeout)
 		defer cancel()
 
-		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+		req, err := http.NewRequestWithContext(reqCtx, "GET", url, nil)
 		if err != nil {
-			return nil, err
+			return nil, 0, err
 		}
 
-		headers, err := c.getHeaders()
+		headers, err := c.taskHeaders(requestID)
 		if err != nil {
-			return nil, err
+			return nil, 0, err
 		}
 		for k, v := range headers {
 			req.Header.Set(k, v)
 		}
 
 		client := &http.Client{
-			Timeout: time.Duration(connectTimeout * float64(time.Second)),
+			Timeout: requestTimeout,
 		}
-		resp, err := client.Do(req)
+		resp, err := c.do(client, req)
 		if err != nil {
 			lastErr = err
+			if ctx.Err() != nil {
+				return nil, 0, ctx.Err()
+			}
 			if retry < c.maxConnectionRetries {
 				delay := c.retryInterval * float64(retry+1)
-				fmt.Printf("Connection error getting result on attempt %d/%d:\n", retry+1, c.maxConnectionRetries+1)
-				fmt.Printf("%v\n", err)
-				fmt.Printf("Retrying in %.1f seconds...\n", delay)
-				time.Sleep(time.Duration(delay * float64(time.Second)))
+				c.logf("Connection error getting result on attempt %d/%d:\n", retry+1, c.maxConnectionRetries+1)
+				c.logf("%v\n", err)
+				c.logf("Retrying in %.1f seconds...\n", delay)
+				timer := time.NewTimer(time.Duration(delay * float64(time.Second)))
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return nil, 0, ctx.Err()
+				case <-timer.C:
+				}
 				continue
 			}
-			return nil, fmt.Errorf("failed to get result for task %s after %d attempts: %w", requestID, c.maxConnectionRetries+1, lastErr)
+			return nil, 0, fmt.Errorf("failed to get result for task %s after %d attempts: %w", requestID, c.maxConnectionRetries+1, lastErr)
 		}
 		defer resp.Body.Close()
 
+		hint := retryAfter(resp.Header.Get("Retry-After"))
 		if resp.StatusCode != 200 {
 			bodyText, _ := io.ReadAll(resp.Body)
-			return nil, fmt.Errorf("failed to get result for task %s: HTTP %d: %s", requestID, resp.StatusCode, string(bodyText))
+			var err error = &statusError{code: resp.StatusCode, msg: fmt.Sprintf("failed to get result for task %s: HTTP %d: %s", requestID, resp.StatusCode, string(bodyText))}
+			if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone {
+				err = fmt.Errorf("%w: %w", ErrTaskNotFound, err)
+			}
+			return nil, hint, err
 		}
 
 		var result map[string]any
 		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-			return nil, err
+			return nil, 0, err
 		}
 
-		return result, nil
+		return result, hint, nil
 	}
 
-	return nil, fmt.Errorf("failed to get result for task %s after %d attempts: %w", requestID, c.maxConnectionRetries+1, lastErr)
+	return nil, 0, fmt.Errorf("failed to get result for task %s after %d attempts: %w", requestID, c.maxConnectionRetries+1, lastErr)
 }
 
-func (c *Client) wait(requestID string, timeout float64, pollInterval float64) (map[string]any, error) {
+// wait polls requestID until it completes, fails, the timeout in options is
+// reached or ctx is done. model is passed to the poll strategy and may be empty.
+func (c *Client) wait(ctx context.Context, requestID string, model string, options *RunOptions) (map[string]any, error) {
 	startTime := time.Now()
+	timeout := options.Timeout
+	strategy := options.pollStrategy()
 
-	for {
-		if timeout > 0 {
-			elapsed := time.Since(startTime).Seconds()
-			if elapsed >= timeout {
-				return nil, fmt.Errorf("prediction timed out after %.0f seconds (task_id: %s)", timeout, requestID)
-			}
+	// The task's route is kept after a timeout so it can still be polled later.
+	finished := false
+	defer func() { c.finishTask(requestID, finished) }()
+
+	// remaining returns the time left before the task deadline.
+	remaining := func() time.Duration {
+		if timeout <= 0 {
+			return time.Duration(math.MaxInt64)
 		}
+		return time.Duration(timeout*float64(time.Second)) - time.Since(startTime)
+	}
+	timedOut := func() error {
+		return &timeoutError{msg: fmt.Sprintf("prediction timed out after %.0f seconds (task_id: %s)", timeout, requestID)}
+	}
 
-		result, err := c.getResult(requestID, timeout)
-		if err != nil {
-			return nil, err
+	// Polls, including their connection retries, stop at the task deadline.
+	pollCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		pollCtx, cancel = context.WithTimeout(ctx, remaining())
+		defer cancel()
+	}
+
+	for attempt := 0; ; attempt++ {
+		left := remaining()
+		if left <= 0 {
+			return nil, timedOut()
 		}
 
-		data, ok := result["data"].(map[string]any)
-		if !ok {
-			return nil, errors.New("invalid response format")
+		requestTimeout := c.pollTimeout(options)
+		if requestTimeout > left {
+			requestTimeout = left
 		}
 
-		status, ok := data["status"].(string)
-		if !ok {
-			return nil, errors.New("missing status in response")
+		result, hint, err := c.pollResult(pollCtx, requestID, requestTimeout)
+		if err != nil && ctx.Err() == nil && pollCtx.Err() != nil {
+			return nil, timedOut()
+		}
+		if err != nil && !(hint > 0 && isRateLimitError(err)) {
+			if errors.Is(err, ErrTaskNotFound) {
+				finished = true
+				c.recordResult(requestID, TaskFailed, nil, err.Error())
+			}
+			return nil, err
 		}
 
-		if status == "completed" {
-			outputs, ok := data["outputs"]
+		if err == nil {
+			data, ok := result["data"].(map[string]any)
 			if !ok {
-				outputs = []any{}
+				return nil, errors.New("invalid response format")
 			}
-			return map[string]any{"outputs": outputs}, nil
-		}
 
-		if status == "failed" {
-			errorMsg := "Unknown error"
-			if e, ok := data["error"].(string); ok && e != "" {
-				errorMsg = e
+			status, ok := data["status"].(string)
+			if !ok {
+				return nil, errors.New("missing status in response")
+			}
+
+			if status == "completed" {
+				finished = true
+				outputs, ok := data["outputs"]
+				if !ok {
+					outputs = []any{}
+				}
+				c.recordResult(requestID, TaskCompleted, outputList(outputs), "")
+				return map[string]any{"outputs": outputs}, nil
+			}
+
+			if status == "failed" {
+				finished = true
+				errorMsg := "Unknown error"
+				if e, ok := data["error"].(string); ok && e != "" {
+					errorMsg = e
+				}
+				c.recordResult(requestID, TaskFailed, nil, errorMsg)
+				return nil, fmt.Errorf("prediction failed (task_id: %s): %s", requestID, errorMsg)
 			}
-			return nil, fmt.Errorf("prediction failed (task_id: %s): %s", requestID, errorMsg)
 		}
 
-		time.Sleep(time.Duration(pollInterval * float64(time.Second)))
+		delay := strategy.NextPoll(PollState{
+			Model:   model,
+			Attempt: attempt,
+			Elapsed: time.Since(startTime),
+			Hint:    hint,
+		})
+		if delay < hint {
+			delay = hint
+		}
+		if left := remaining(); delay > left {
+			delay = left
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
 	}
 }
 
@@ -427,6 +689,10 @@ func (c *Client) isRetryableError(err error) bool {
 	if err == nil {
 		return false
 	}
+	// An open circuit fails fast; its message may quote a connection error.
+	if errors.Is(err, ErrCircuitOpen) {
+		return false
+	}
 
 	errStr := strings.ToLower(err.Error())
 	if strings.Contains(errStr, "sync mode timed out") {
@@ -438,6 +704,22 @@ func (c *Client) isRetryableError(err error) bool {
 		strings.Contains(errStr, "429")
 }
 
+// statusError is an HTTP error response from the API.
+type statusError struct {
+	code int
+	msg  string
+}
+
+func (e *statusError) Error() string { return e.msg }
+
+// timeoutError reports a task that did not finish within its timeout. The
+// task may still be running.
+type timeoutError struct {
+	msg string
+}
+
+func (e *timeoutError) Error() string { return e.msg }
+
 func syncResultURL(data map[string]any) string {
 	switch urls := data["urls"].(type) {
 	case map[string]string:
@@ -485,14 +767,27 @@ func syncModeError(data map[string]any) error {
 		if resultURL := syncResultURL(data); resultURL != "" && !strings.Contains(message, resultURL) {
 			message += " Query the result later at: " + resultURL
 		}
-		return errors.New(message)
+		return &timeoutError{msg: message}
 	}
 
 	return fmt.Errorf("prediction failed (task_id: %s): %s", requestID, errorMsg)
 }
 
-// Run executes a model and waits for the output.
-func (c *Client) Run(model string, input map[string]any, opts ...RunOption) (map[string]any, error) {
+// syncFallbackOptions returns options for polling a task after a sync timeout
+// for whatever is left of the run's timeout.
+func syncFallbackOptions(options *RunOptions, startTime time.Time) *RunOptions {
+	fallback := *options
+	if fallback.Timeout > 0 {
+		fallback.Timeout -= time.Since(startTime).Seconds()
+		if fallback.Timeout <= 0 {
+			// A zero timeout means no timeout, so expire immediately instead.
+			fallback.Timeout = math.SmallestNonzeroFloat64
+		}
+	}
+	return &fallback
+}
+
+func (c *Client) newRunOptions(opts []RunOption) *RunOptions {
 	// Apply default options
 	options := &RunOptions{
 		Timeout:        36000.0,
@@ -505,37 +800,105 @@ func (c *Client) Run(model string, input map[string]any, opts ...RunOption) (map
 	for _, opt := range opts {
 		opt(options)
 	}
+	return options
+}
+
+// Run executes a model and waits for the output.
+func (c *Client) Run(model string, input map[string]any, opts ...RunOption) (map[string]any, error) {
+	pred, err := c.run(context.Background(), model, input, c.newRunOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{"outputs": pred.rawOutputs()}, nil
+}
+
+// RunContext is Run with a context. Cancelling ctx stops waiting for the task
+// and any retry delay, and returns ctx.Err().
+func (c *Client) RunContext(ctx context.Context, model string, input map[string]any, opts ...RunOption) (map[string]any, error) {
+	pred, err := c.run(ctx, model, input, c.newRunOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{"outputs": pred.rawOutputs()}, nil
+}
+
+// run runs model, falling back to the models set with WithFallbackModels,
+// and returns the completed prediction of the model that served it.
+func (c *Client) run(ctx context.Context, model string, input map[string]any, options *RunOptions) (*Prediction, error) {
+	chain := options.modelChain(model)
+	for i, m := range chain {
+		in, opts, err := options.fallbackAttempt(i, m, input)
+		if err == nil {
+			var pred *Prediction
+			pred, err = c.runModel(ctx, m, in, opts)
+			if err == nil {
+				return pred, nil
+			}
+		}
+		if i == len(chain)-1 || ctx.Err() != nil || !c.isFallbackError(err) {
+			return nil, err
+		}
+		c.logFallback(m, err, chain[i+1])
+	}
+	return nil, fmt.Errorf("no model to run")
+}
 
+// runModel submits input to model, retrying as configured in options, and
+// waits for the task to complete. It returns the completed prediction.
+func (c *Client) runModel(ctx context.Context, model string, input map[string]any, options *RunOptions) (*Prediction, error) {
 	timeout := options.Timeout
-	pollInterval := options.PollInterval
 	enableSyncMode := options.EnableSyncMode
 	taskRetries := options.MaxRetries
+	startTime := time.Now()
+
+	if err := c.validateInput(ctx, model, input, options); err != nil {
+		return nil, err
+	}
+
+	// completed wraps the result of wait in a prediction.
+	completed := func(taskID string, result map[string]any, err error) (*Prediction, error) {
+		if err != nil {
+			return nil, err
+		}
+		raw := result["outputs"]
+		return &Prediction{ID: taskID, Model: model, Status: "completed", Outputs: outputList(raw), raw: raw}, nil
+	}
 
 	var lastError error
 
 	for attempt := 0; attempt <= taskRetries; attempt++ {
-		requestID, syncResult, err := c.submit(model, input, enableSyncMode, timeout)
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		requestID, syncResult, err := c.submit(ctx, model, input, enableSyncMode, timeout, options.IdempotencyKey)
 		if err == nil {
-			if enableSyncMode {
+			// A task reused through an idempotency key has no sync result and is
+			// waited on like an async one.
+			if enableSyncMode && syncResult != nil {
 				// In sync mode, extract outputs from the result
 				data, ok := syncResult["data"].(map[string]any)
 				if !ok {
-					return map[string]any{"outputs": []any{}}, nil
+					return &Prediction{ID: requestID, Model: model, Status: "completed", Outputs: []any{}}, nil
 				}
 
+				taskID, _ := data["id"].(string)
 				status, _ := data["status"].(string)
 				if status != "completed" {
+					if taskID != "" && options.SyncFallback && isSyncTimeoutData(data) {
+						result, err := c.wait(ctx, taskID, model, syncFallbackOptions(options, startTime))
+						return completed(taskID, result, err)
+					}
 					return nil, syncModeError(data)
 				}
 
-				outputs, ok := data["outputs"]
-				if !ok {
-					outputs = []any{}
-				}
-				return map[string]any{"outputs": outputs}, nil
+				raw := data["outputs"]
+				createdAt, _ := data["created_at"].(string)
+				return &Prediction{ID: taskID, Model: model, Status: "completed", Outputs: outputList(raw), CreatedAt: createdAt, raw: raw}, nil
 			}
 
-			return c.wait(requestID, timeout, pollInterval)
+			result, err := c.wait(ctx, requestID, model, options)
+			return completed(requestID, result, err)
 		}
 
 		lastError = err
@@ -546,9 +909,15 @@ func (c *Client) Run(model string, input map[string]any, opts ...RunOption) (map
 		}
 
 		delay := c.retryInterval * float64(attempt+1)
-		fmt.Printf("Task attempt %d/%d failed: %v\n", attempt+1, taskRetries+1, err)
-		fmt.Printf("Retrying in %.1f seconds...\n", delay)
-		time.Sleep(time.Duration(delay * float64(time.Second)))
+		c.logf("Task attempt %d/%d failed: %v\n", attempt+1, taskRetries+1, err)
+		c.logf("Retrying in %.1f seconds...\n", delay)
+		timer := time.NewTimer(time.Duration(delay * float64(time.Second)))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
 	}
 
 	if lastError != nil {
@@ -565,12 +934,17 @@ type RunDetail struct {
 	Error     string `json:"error,omitempty"`
 	CreatedAt string `json:"createdAt,omitempty"`
 	ResultURL string `json:"resultUrl,omitempty"`
+	// Variant is the Router variant that ran the task, if any.
+	Variant string `json:"variant,omitempty"`
 }
 
 // RunNoThrowResult is the result of RunNoThrow method.
 type RunNoThrowResult struct {
 	Outputs []any     `json:"outputs"`
 	Detail  RunDetail `json:"detail"`
+
+	// err is the failure behind Detail.Error, used to decide on fallbacks.
+	err error
 }
 
 // RunNoThrow executes a model and waits for the output (no-throw version).
@@ -591,28 +965,84 @@ type RunNoThrowResult struct {
 //	    fmt.Println("Task ID:", result.Detail.TaskID)
 //	}
 func (c *Client) RunNoThrow(model string, input map[string]any, opts ...RunOption) *RunNoThrowResult {
-	// Apply default options
-	options := &RunOptions{
-		Timeout:        36000.0,
-		PollInterval:   1.0,
-		EnableSyncMode: false,
-		MaxRetries:     c.maxRetries,
-	}
+	return c.runNoThrow(context.Background(), model, input, c.newRunOptions(opts))
+}
 
-	// Apply user-provided options
-	for _, opt := range opts {
-		opt(options)
+// RunNoThrowContext is RunNoThrow with a context. Cancelling ctx stops
+// waiting for the task and any retry delay, and reports ctx.Err() in the
+// detail.
+func (c *Client) RunNoThrowContext(ctx context.Context, model string, input map[string]any, opts ...RunOption) *RunNoThrowResult {
+	return c.runNoThrow(ctx, model, input, c.newRunOptions(opts))
+}
+
+// runNoThrow is run reporting the outcome as a RunNoThrowResult.
+func (c *Client) runNoThrow(ctx context.Context, model string, input map[string]any, options *RunOptions) *RunNoThrowResult {
+	chain := options.modelChain(model)
+	for i, m := range chain {
+		in, attemptOptions, err := options.fallbackAttempt(i, m, input)
+		if err != nil {
+			return &RunNoThrowResult{
+				Outputs: nil,
+				Detail: RunDetail{
+					TaskID: "unknown",
+					Status: "failed",
+					Model:  m,
+					Error:  err.Error(),
+				},
+				err: err,
+			}
+		}
+		result := c.runNoThrowModel(ctx, m, in, attemptOptions)
+		if result.Outputs != nil || i == len(chain)-1 || ctx.Err() != nil || !c.isFallbackError(result.err) {
+			return result
+		}
+		c.logFallback(m, result.err, chain[i+1])
 	}
+	return nil
+}
 
+// runNoThrowModel is runNoThrow for a single model.
+func (c *Client) runNoThrowModel(ctx context.Context, model string, input map[string]any, options *RunOptions) *RunNoThrowResult {
 	timeout := options.Timeout
-	pollInterval := options.PollInterval
 	enableSyncMode := options.EnableSyncMode
 	taskRetries := options.MaxRetries
+	startTime := time.Now()
+
+	if err := c.validateInput(ctx, model, input, options); err != nil {
+		return &RunNoThrowResult{
+			Outputs: nil,
+			Detail: RunDetail{
+				TaskID: "unknown",
+				Status: "failed",
+				Model:  model,
+				Error:  err.Error(),
+			},
+			err: err,
+		}
+	}
+
+	// cancelled reports the run being cancelled through ctx.
+	cancelled := func(err error) *RunNoThrowResult {
+		return &RunNoThrowResult{
+			Outputs: nil,
+			Detail: RunDetail{
+				TaskID: "unknown",
+				Status: "failed",
+				Model:  model,
+				Error:  err.Error(),
+			},
+			err: err,
+		}
+	}
 
 	for attempt := 0; attempt <= taskRetries; attempt++ {
-		requestID, syncResult, err := c.submit(model, input, enableSyncMode, timeout)
+		if err := ctx.Err(); err != nil {
+			return cancelled(err)
+		}
+
+		requestID, syncResult, err := c.submit(ctx, model, input, enableSyncMode, timeout, options.IdempotencyKey)
 		if err == nil {
-			if enableSyncMode {
+			if enableSyncMode && syncResult != nil {
 				// In sync mode, extract outputs from the result
 				data, ok := syncResult["data"].(map[string]any)
 				if !ok {
@@ -634,6 +1064,9 @@ func (c *Client) RunNoThrow(model string, input map[string]any, opts ...RunOptio
 				}
 
 				if status != "completed" {
+					if taskID != "unknown" && options.SyncFallback && isSyncTimeoutData(data) {
+						return c.waitNoThrow(ctx, taskID, model, syncFallbackOptions(options, startTime))
+					}
 					errorMsg := "Unknown error"
 					if e, ok := data["error"].(string); ok && e != "" {
 						errorMsg = e
@@ -641,9 +1074,10 @@ func (c *Client) RunNoThrow(model string, input map[string]any, opts ...RunOptio
 					createdAt, _ := data["created_at"].(string)
 					resultURL := syncResultURL(data)
 					detailStatus := "failed"
+					syncErr := syncModeError(data)
 					if isSyncTimeoutData(data) {
 						detailStatus = "processing"
-						errorMsg = syncModeError(data).Error()
+						errorMsg = syncErr.Error()
 					}
 					return &RunNoThrowResult{
 						Outputs: nil,
@@ -655,16 +1089,13 @@ func (c *Client) RunNoThrow(model string, input map[string]any, opts ...RunOptio
 							CreatedAt: createdAt,
 							ResultURL: resultURL,
 						},
+						err: syncErr,
 					}
 				}
 
-				outputs, ok := data["outputs"].([]any)
-				if !ok {
-					outputs = []any{}
-				}
 				createdAt, _ := data["created_at"].(string)
 				return &RunNoThrowResult{
-					Outputs: outputs,
+					Outputs: outputList(data["outputs"]),
 					Detail: RunDetail{
 						TaskID:    taskID,
 						Status:    "completed",
@@ -675,32 +1106,7 @@ func (c *Client) RunNoThrow(model string, input map[string]any, opts ...RunOptio
 			}
 
 			// Async mode
-			result, err := c.wait(requestID, timeout, pollInterval)
-			if err == nil {
-				outputs, ok := result["outputs"].([]any)
-				if !ok {
-					outputs = []any{}
-				}
-				return &RunNoThrowResult{
-					Outputs: outputs,
-					Detail: RunDetail{
-						TaskID: requestID,
-						Status: "completed",
-						Model:  model,
-					},
-				}
-			}
-
-			// Wait failed, but we have taskID
-			return &RunNoThrowResult{
-				Outputs: nil,
-				Detail: RunDetail{
-					TaskID: requestID,
-					Status: "failed",
-					Model:  model,
-					Error:  err.Error(),
-				},
-			}
+			return c.waitNoThrow(ctx, requestID, model, options)
 		}
 
 		// Submit failed
@@ -729,13 +1135,20 @@ func (c *Client) RunNoThrow(model string, input map[string]any, opts ...RunOptio
 					Model:  model,
 					Error:  err.Error(),
 				},
+				err: err,
 			}
 		}
 
 		delay := c.retryInterval * float64(attempt+1)
-		fmt.Printf("Task attempt %d/%d failed: %v\n", attempt+1, taskRetries+1, err)
-		fmt.Printf("Retrying in %.1f seconds...\n", delay)
-		time.Sleep(time.Duration(delay * float64(time.Second)))
+		c.logf("Task attempt %d/%d failed: %v\n", attempt+1, taskRetries+1, err)
+		c.logf("Retrying in %.1f seconds...\n", delay)
+		timer := time.NewTimer(time.Duration(delay * float64(time.Second)))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return cancelled(ctx.Err())
+		case <-timer.C:
+		}
 	}
 
 	// Should not reach here
@@ -750,10 +1163,44 @@ func (c *Client) RunNoThrow(model string, input map[string]any, opts ...RunOptio
 	}
 }
 
+// waitNoThrow waits for requestID and reports the outcome as a RunNoThrowResult.
+func (c *Client) waitNoThrow(ctx context.Context, requestID string, model string, options *RunOptions) *RunNoThrowResult {
+	result, err := c.wait(ctx, requestID, model, options)
+	if err == nil {
+		return &RunNoThrowResult{
+			Outputs: outputList(result["outputs"]),
+			Detail: RunDetail{
+				TaskID: requestID,
+				Status: "completed",
+				Model:  model,
+			},
+		}
+	}
+
+	// Wait failed, but we have taskID
+	return &RunNoThrowResult{
+		Outputs: nil,
+		Detail: RunDetail{
+			TaskID: requestID,
+			Status: "failed",
+			Model:  model,
+			Error:  err.Error(),
+		},
+		err: err,
+	}
+}
+
 // Upload uploads a file to WaveSpeed.
 func (c *Client) Upload(file string, opts ...UploadOption) (string, error) {
-	if c.apiKey == "" {
-		return "", errors.New("API key is required. Set WAVESPEED_API_KEY environment variable or pass api_key to Client()")
+	apiKey := c.keys.acquire()
+	defer c.keys.release(apiKey)
+	if apiKey == "" {
+		var err error
+		if apiKey, err = c.resolveAPIKey(); err != nil {
+			return "", err
+		}
+	} else if c.configErr != nil {
+		return "", c.configErr
 	}
 
 	// Apply default options
@@ -766,9 +1213,9 @@ func (c *Client) Upload(file string, opts ...UploadOption) (string, error) {
 		opt(options)
 	}
 
-	url := c.baseURL + "/api/v3/media/upload/binary"
+	url := c.endpointOrder()[0] + "/api/v3/media/upload/binary"
 	headers := map[string]string{
-		"Authorization": "Bearer " + c.apiKey,
+		"Authorization": "Bearer " + apiKey,
 	}
 	requestTimeout := options.Timeout
 
@@ -812,7 +1259,7 @@ func (c *Client) Upload(file string, opts ...UploadOption) (string, error) {
 	client := &http.Client{
 		Timeout: time.Duration(requestTimeout * float64(time.Second)),
 	}
-	resp, err := client.Do(req)
+	resp, err := c.do(client, req)
 	if err != nil {
 		return "", err
 	}
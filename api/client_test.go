@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"errors"
 	"io"
 	"net/http"
@@ -9,6 +10,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestInitWithAPIKey(t *testing.T) {
@@ -64,7 +66,7 @@ func TestSubmitSuccess(t *testing.T) {
 	defer server.Close()
 
 	client := NewClient(WithAPIKey("test-key"), WithBaseURL(server.URL))
-	requestID, result, err := client.submit("wavespeed-ai/z-image/turbo", map[string]any{"prompt": "test"}, false, 0)
+	requestID, result, err := client.submit(context.Background(), "wavespeed-ai/z-image/turbo", map[string]any{"prompt": "test"}, false, 0, "")
 	if err != nil {
 		t.Fatalf("submit error: %v", err)
 	}
@@ -86,7 +88,7 @@ func TestSubmitFailure(t *testing.T) {
 	defer server.Close()
 
 	client := NewClient(WithAPIKey("test-key"), WithBaseURL(server.URL))
-	_, _, err := client.submit("wavespeed-ai/z-image/turbo", map[string]any{"prompt": "test"}, false, 0)
+	_, _, err := client.submit(context.Background(), "wavespeed-ai/z-image/turbo", map[string]any{"prompt": "test"}, false, 0, "")
 	if err == nil {
 		t.Fatal("expected error for HTTP 500")
 	}
@@ -95,7 +97,7 @@ func TestSubmitFailure(t *testing.T) {
 	}
 }
 
-func TestGetResultSuccess(t *testing.T) {
+func TestPollResultSuccess(t *testing.T) {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/api/v3/predictions/req-123/result", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -105,9 +107,9 @@ func TestGetResultSuccess(t *testing.T) {
 	defer server.Close()
 
 	client := NewClient(WithAPIKey("test-key"), WithBaseURL(server.URL))
-	result, err := client.getResult("req-123", 0)
+	result, _, err := client.pollResult(context.Background(), "req-123", 5*time.Second)
 	if err != nil {
-		t.Fatalf("getResult error: %v", err)
+		t.Fatalf("pollResult error: %v", err)
 	}
 	data, ok := result["data"].(map[string]any)
 	if !ok {
@@ -378,6 +380,64 @@ func TestRunNoThrowSyncModeTimeoutReturnsProcessing(t *testing.T) {
 	}
 }
 
+func TestRunSyncFallbackPollsAfterTimeout(t *testing.T) {
+	resultHits := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/wavespeed-ai/z-image/turbo", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"code":200,"data":{"id":"req-timeout","status":"processing","code":5004,"error":"Sync mode timed out after 90 seconds.","outputs":[]}}`))
+	})
+	mux.HandleFunc("/api/v3/predictions/req-timeout/result", func(w http.ResponseWriter, r *http.Request) {
+		resultHits++
+		if resultHits < 2 {
+			w.Write([]byte(`{"code":200,"data":{"id":"req-timeout","status":"processing"}}`))
+			return
+		}
+		w.Write([]byte(`{"code":200,"data":{"id":"req-timeout","status":"completed","outputs":["https://example.com/out.png"]}}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := NewClient(WithAPIKey("test-key"), WithBaseURL(server.URL))
+	result, err := client.Run("wavespeed-ai/z-image/turbo", nil, WithSyncMode(true), WithSyncFallback(), WithPollInterval(0.01))
+	if err != nil {
+		t.Fatalf("run error: %v", err)
+	}
+	if outputs := result["outputs"].([]any); len(outputs) != 1 {
+		t.Errorf("unexpected outputs: %+v", result)
+	}
+	if resultHits != 2 {
+		t.Errorf("expected 2 polls after the sync timeout, got %d", resultHits)
+	}
+
+	detail := client.RunNoThrow("wavespeed-ai/z-image/turbo", nil, WithSyncMode(true), WithSyncFallback(), WithPollInterval(0.01))
+	if detail.Outputs == nil || detail.Detail.TaskID != "req-timeout" || detail.Detail.Status != "completed" {
+		t.Errorf("unexpected RunNoThrow result: %+v", detail)
+	}
+}
+
+func TestRunSyncFallbackRespectsOverallTimeout(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/wavespeed-ai/z-image/turbo", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(150 * time.Millisecond)
+		w.Write([]byte(`{"code":200,"data":{"id":"req-timeout","status":"processing","code":5004,"error":"Sync mode timed out after 90 seconds.","outputs":[]}}`))
+	})
+	mux.HandleFunc("/api/v3/predictions/req-timeout/result", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"code":200,"data":{"id":"req-timeout","status":"processing"}}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := NewClient(WithAPIKey("test-key"), WithBaseURL(server.URL))
+	start := time.Now()
+	_, err := client.Run("wavespeed-ai/z-image/turbo", nil, WithSyncMode(true), WithSyncFallback(), WithTimeout(0.4), WithPollInterval(0.01))
+	if err == nil || !strings.Contains(err.Error(), "timed out") || !strings.Contains(err.Error(), "req-timeout") {
+		t.Fatalf("expected timeout error with task id, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 700*time.Millisecond {
+		t.Errorf("expected polling to stop at the overall timeout, took %v", elapsed)
+	}
+}
+
 func TestRunTimeout(t *testing.T) {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/api/v3/wavespeed-ai/z-image/turbo", func(w http.ResponseWriter, r *http.Request) {
@@ -551,8 +611,29 @@ func TestRunAllRetriesFailed(t *testing.T) {
 	}
 }
 
-func TestGetResultConnectionRetry(t *testing.T) {
-	// Test that getResult does NOT retry on HTTP status code errors (only on connection errors)
+func TestRunNoThrowRetryDelayRespectsContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte("Service Unavailable"))
+	}))
+	defer server.Close()
+
+	client := NewClient(WithAPIKey("test-key"), WithBaseURL(server.URL), WithRetryInterval(10), WithLogOutput(io.Discard))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	result := client.runNoThrow(ctx, "wavespeed-ai/z-image/turbo", nil, client.newRunOptions([]RunOption{WithMaxRetries(3)}))
+	if result.Outputs != nil || !strings.Contains(result.Detail.Error, "context deadline exceeded") {
+		t.Errorf("expected cancellation in detail, got %+v", result.Detail)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected the retry delay to stop at cancellation, took %v", elapsed)
+	}
+}
+
+func TestPollResultConnectionRetry(t *testing.T) {
+	// Test that pollResult does NOT retry on HTTP status code errors (only on connection errors)
 	attemptCount := 0
 	mux := http.NewServeMux()
 	mux.HandleFunc("/api/v3/predictions/req-123/result", func(w http.ResponseWriter, r *http.Request) {
@@ -565,7 +646,7 @@ func TestGetResultConnectionRetry(t *testing.T) {
 	defer server.Close()
 
 	client := NewClient(WithAPIKey("test-key"), WithBaseURL(server.URL), WithMaxConnectionRetries(5), WithRetryInterval(0.01))
-	_, err := client.getResult("req-123", 0)
+	_, _, err := client.pollResult(context.Background(), "req-123", 5*time.Second)
 
 	if err == nil {
 		t.Fatal("expected error for HTTP 500")
@@ -625,7 +706,7 @@ func TestSubmitConnectionRetry(t *testing.T) {
 	defer server.Close()
 
 	client := NewClient(WithAPIKey("test-key"), WithBaseURL(server.URL), WithMaxConnectionRetries(5), WithRetryInterval(0.01))
-	_, _, err := client.submit("wavespeed-ai/z-image/turbo", map[string]any{"prompt": "test"}, false, 0)
+	_, _, err := client.submit(context.Background(), "wavespeed-ai/z-image/turbo", map[string]any{"prompt": "test"}, false, 0, "")
 
 	if err == nil {
 		t.Fatal("expected error for HTTP 502")
@@ -653,7 +734,7 @@ func TestWaitInvalidResponse(t *testing.T) {
 	defer server.Close()
 
 	client := NewClient(WithAPIKey("test-key"), WithBaseURL(server.URL))
-	_, err := client.wait("req-123", 0.1, 0.01)
+	_, err := client.wait(context.Background(), "req-123", "", &RunOptions{Timeout: 0.1, PollInterval: 0.01})
 
 	if err == nil {
 		t.Fatal("expected error for invalid response format")
@@ -664,7 +745,7 @@ func TestWaitInvalidResponse(t *testing.T) {
 	}
 }
 
-func TestGetResultNon200Status(t *testing.T) {
+func TestPollResultNon200Status(t *testing.T) {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/api/v3/predictions/req-123/result", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusNotFound)
@@ -674,7 +755,7 @@ func TestGetResultNon200Status(t *testing.T) {
 	defer server.Close()
 
 	client := NewClient(WithAPIKey("test-key"), WithBaseURL(server.URL))
-	_, err := client.getResult("req-123", 0)
+	_, _, err := client.pollResult(context.Background(), "req-123", 5*time.Second)
 
 	if err == nil {
 		t.Fatal("expected error for HTTP 404")
@@ -700,7 +781,7 @@ func TestSubmitMissingRequestID(t *testing.T) {
 	defer server.Close()
 
 	client := NewClient(WithAPIKey("test-key"), WithBaseURL(server.URL))
-	_, _, err := client.submit("wavespeed-ai/z-image/turbo", map[string]any{"prompt": "test"}, false, 0)
+	_, _, err := client.submit(context.Background(), "wavespeed-ai/z-image/turbo", map[string]any{"prompt": "test"}, false, 0, "")
 
 	if err == nil {
 		t.Fatal("expected error for missing request ID")
@@ -723,7 +804,7 @@ func TestWaitMissingStatus(t *testing.T) {
 	defer server.Close()
 
 	client := NewClient(WithAPIKey("test-key"), WithBaseURL(server.URL))
-	_, err := client.wait("req-123", 0.1, 0.01)
+	_, err := client.wait(context.Background(), "req-123", "", &RunOptions{Timeout: 0.1, PollInterval: 0.01})
 
 	if err == nil {
 		t.Fatal("expected error for missing status")
@@ -733,3 +814,35 @@ func TestWaitMissingStatus(t *testing.T) {
 		t.Errorf("expected 'missing status' error, got: %v", err)
 	}
 }
+
+func TestScalarOutputs(t *testing.T) {
+	const data = `{"id":"req-1","status":"completed","outputs":"A cat on a sofa"}`
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/acme/captioner", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"code":200,"data":` + data + `}`))
+	})
+	mux.HandleFunc("/api/v3/predictions/req-1/result", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"code":200,"data":` + data + `}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	client := NewClient(WithAPIKey("test-key"), WithBaseURL(server.URL))
+
+	output, err := client.Run("acme/captioner", nil, WithSyncMode(true))
+	if err != nil || output["outputs"] != "A cat on a sofa" {
+		t.Errorf("unexpected sync run result: %v, %v", output, err)
+	}
+	result := client.RunNoThrow("acme/captioner", nil, WithSyncMode(true))
+	if len(result.Outputs) != 1 || result.Outputs[0] != "A cat on a sofa" {
+		t.Errorf("unexpected sync RunNoThrow result: %+v", result)
+	}
+
+	pred, err := client.GetPrediction("req-1")
+	if err != nil || len(pred.Outputs) != 1 || pred.Outputs[0] != "A cat on a sofa" {
+		t.Errorf("unexpected prediction: %+v, %v", pred, err)
+	}
+	attached := client.Attach(context.Background(), "req-1")
+	if attached.Detail.Status != "completed" || len(attached.Outputs) != 1 {
+		t.Errorf("unexpected attach result: %+v", attached)
+	}
+}
@@ -0,0 +1,210 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CredentialsProvider supplies the API key used by a Client.
+//
+// The provider is consulted before every request, so rotating the key in the
+// underlying source takes effect without recreating the client. When the API
+// rejects a key with HTTP 401, the client calls Refresh and retries once.
+type CredentialsProvider interface {
+	// APIKey returns the key to send with the next request.
+	APIKey() (string, error)
+	// Refresh discards any cached key so the next APIKey call fetches a new one.
+	Refresh() error
+}
+
+// WithCredentials sets the provider consulted for the API key on each request.
+// It takes precedence over WithAPIKey.
+//
+// Example:
+//
+//	client := api.NewClient(api.WithCredentials(api.FileCredentials("/run/secrets/wavespeed")))
+func WithCredentials(provider CredentialsProvider) ClientOption {
+	return func(c *Client) {
+		c.credentials = provider
+	}
+}
+
+type staticCredentials string
+
+// StaticCredentials returns a provider that always returns key.
+func StaticCredentials(key string) CredentialsProvider {
+	return staticCredentials(key)
+}
+
+func (s staticCredentials) APIKey() (string, error) { return string(s), nil }
+
+func (s staticCredentials) Refresh() error { return nil }
+
+type envCredentials string
+
+// EnvCredentials returns a provider that reads the key from the named
+// environment variable on every request.
+func EnvCredentials(name string) CredentialsProvider {
+	return envCredentials(name)
+}
+
+func (e envCredentials) APIKey() (string, error) { return os.Getenv(string(e)), nil }
+
+func (e envCredentials) Refresh() error { return nil }
+
+// FileCredentialsProvider reads the key from a file and re-reads it whenever
+// the file changes. Surrounding whitespace is ignored.
+type FileCredentialsProvider struct {
+	path string
+
+	mu      sync.Mutex
+	key     string
+	modTime time.Time
+	size    int64
+}
+
+// FileCredentials returns a provider that reads the key from path, such as a
+// mounted Kubernetes secret.
+func FileCredentials(path string) *FileCredentialsProvider {
+	return &FileCredentialsProvider{path: path}
+}
+
+// APIKey returns the key from the file, reading it again if it changed.
+func (f *FileCredentialsProvider) APIKey() (string, error) {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read credentials file: %w", err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.key != "" && info.ModTime().Equal(f.modTime) && info.Size() == f.size {
+		return f.key, nil
+	}
+
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read credentials file: %w", err)
+	}
+	f.key = string(bytes.TrimSpace(data))
+	f.modTime = info.ModTime()
+	f.size = info.Size()
+	return f.key, nil
+}
+
+// Refresh forces the file to be read again on the next request.
+func (f *FileCredentialsProvider) Refresh() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.key = ""
+	return nil
+}
+
+// CachingCredentialsProvider caches the key returned by a fetch function,
+// such as a secrets manager lookup, and fetches it again after a TTL.
+type CachingCredentialsProvider struct {
+	fetch func() (string, error)
+	ttl   time.Duration
+
+	mu        sync.Mutex
+	key       string
+	expiresAt time.Time
+}
+
+// CachingCredentials returns a provider that calls fetch at most once per ttl.
+// A ttl of zero caches the key until Refresh is called.
+//
+// Example:
+//
+//	provider := api.CachingCredentials(func() (string, error) {
+//	    return secrets.Get(ctx, "wavespeed-api-key")
+//	}, 15*time.Minute)
+func CachingCredentials(fetch func() (string, error), ttl time.Duration) *CachingCredentialsProvider {
+	return &CachingCredentialsProvider{fetch: fetch, ttl: ttl}
+}
+
+// APIKey returns the cached key, fetching a new one if it expired.
+func (p *CachingCredentialsProvider) APIKey() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.key != "" && (p.ttl == 0 || time.Now().Before(p.expiresAt)) {
+		return p.key, nil
+	}
+
+	key, err := p.fetch()
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch credentials: %w", err)
+	}
+	p.key = strings.TrimSpace(key)
+	p.expiresAt = time.Now().Add(p.ttl)
+	return p.key, nil
+}
+
+// Refresh drops the cached key so the next request fetches a new one.
+func (p *CachingCredentialsProvider) Refresh() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.key = ""
+	return nil
+}
+
+var errMissingAPIKey = errors.New("API key is required. Set WAVESPEED_API_KEY environment variable or pass api_key to Client()")
+
+// resolveAPIKey returns the key for the next request.
+func (c *Client) resolveAPIKey() (string, error) {
+	if c.configErr != nil {
+		return "", c.configErr
+	}
+
+	key := c.apiKey
+	if c.credentials != nil {
+		var err error
+		if key, err = c.credentials.APIKey(); err != nil {
+			return "", err
+		}
+	}
+	if key == "" {
+		return "", errMissingAPIKey
+	}
+	return key, nil
+}
+
+// do sends req and, if the API rejects the key with HTTP 401 and the client
+// has a credentials provider, refreshes the credentials and retries once.
+// If the refresh fails, the original 401 response is returned. Keys from a
+// WithAPIKeys pool are not refreshed.
+func (c *Client) do(httpClient *http.Client, req *http.Request) (*http.Response, error) {
+	resp, err := httpClient.Do(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || c.credentials == nil || !c.keys.empty() {
+		return resp, err
+	}
+
+	if err := c.credentials.Refresh(); err != nil {
+		return resp, nil
+	}
+	key, err := c.credentials.APIKey()
+	if err != nil || key == "" {
+		return resp, nil
+	}
+
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return resp, nil
+		}
+		retry.Body = body
+	}
+	resp.Body.Close()
+
+	retry.Header.Set("Authorization", "Bearer "+key)
+	return httpClient.Do(retry)
+}
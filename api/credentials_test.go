@@ -0,0 +1,140 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFileCredentialsRereadsOnChange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api-key")
+	if err := os.WriteFile(path, []byte("key-1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	provider := FileCredentials(path)
+	if key, err := provider.APIKey(); err != nil || key != "key-1" {
+		t.Fatalf("expected key-1, got %q (%v)", key, err)
+	}
+
+	if err := os.WriteFile(path, []byte("key-22\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if key, err := provider.APIKey(); err != nil || key != "key-22" {
+		t.Errorf("expected rotated key-22, got %q (%v)", key, err)
+	}
+
+	os.Remove(path)
+	if _, err := provider.APIKey(); err == nil {
+		t.Error("expected error for missing credentials file")
+	}
+}
+
+func TestCachingCredentials(t *testing.T) {
+	fetches := 0
+	provider := CachingCredentials(func() (string, error) {
+		fetches++
+		if fetches == 3 {
+			return "", errors.New("secrets manager unavailable")
+		}
+		return "key-" + string(rune('0'+fetches)), nil
+	}, time.Hour)
+
+	for i := 0; i < 3; i++ {
+		if key, _ := provider.APIKey(); key != "key-1" {
+			t.Fatalf("expected cached key-1, got %q", key)
+		}
+	}
+	if fetches != 1 {
+		t.Errorf("expected 1 fetch, got %d", fetches)
+	}
+
+	provider.Refresh()
+	if key, _ := provider.APIKey(); key != "key-2" {
+		t.Errorf("expected key-2 after refresh, got %q", key)
+	}
+
+	provider.Refresh()
+	if _, err := provider.APIKey(); err == nil || !strings.Contains(err.Error(), "secrets manager unavailable") {
+		t.Errorf("expected fetch error, got %v", err)
+	}
+}
+
+func TestEnvCredentials(t *testing.T) {
+	t.Setenv("MY_WAVESPEED_KEY", "env-key")
+	client := NewClient(WithAPIKey("ignored"), WithCredentials(EnvCredentials("MY_WAVESPEED_KEY")))
+	headers, err := client.getHeaders()
+	if err != nil {
+		t.Fatalf("getHeaders error: %v", err)
+	}
+	if headers["Authorization"] != "Bearer env-key" {
+		t.Errorf("expected key from provider, got %s", headers["Authorization"])
+	}
+
+	t.Setenv("MY_WAVESPEED_KEY", "")
+	if _, err := client.getHeaders(); err == nil || !strings.Contains(err.Error(), "API key is required") {
+		t.Errorf("expected missing key error, got %v", err)
+	}
+}
+
+func TestUnauthorizedRefreshesCredentialsAndRetries(t *testing.T) {
+	var auths []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/wavespeed-ai/z-image/turbo", func(w http.ResponseWriter, r *http.Request) {
+		auths = append(auths, r.Header.Get("Authorization"))
+		if r.Header.Get("Authorization") != "Bearer new-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"code":401,"message":"invalid api key"}`))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"code":200,"data":{"id":"req-123"}}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	key := "old-key"
+	provider := CachingCredentials(func() (string, error) { return key, nil }, 0)
+	client := NewClient(WithCredentials(provider), WithBaseURL(server.URL))
+
+	provider.APIKey()
+	key = "new-key"
+
+	taskID, err := client.Submit("wavespeed-ai/z-image/turbo", map[string]any{"prompt": "test"})
+	if err != nil {
+		t.Fatalf("submit error: %v", err)
+	}
+	if taskID != "req-123" {
+		t.Errorf("expected req-123, got %s", taskID)
+	}
+	if strings.Join(auths, ",") != "Bearer old-key,Bearer new-key" {
+		t.Errorf("expected one retry with the refreshed key, got %v", auths)
+	}
+}
+
+func TestUnauthorizedRetriesOnlyOnce(t *testing.T) {
+	attempts := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/predictions/req-123/result", func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"code":401,"message":"invalid api key"}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := NewClient(WithCredentials(StaticCredentials("bad-key")), WithBaseURL(server.URL))
+	_, _, err := client.pollResult(context.Background(), "req-123", 5*time.Second)
+	if err == nil || !strings.Contains(err.Error(), "HTTP 401") {
+		t.Fatalf("expected HTTP 401 error, got %v", err)
+	}
+	if attempts != 2 {
+		t.Errorf("expected 2 attempts, got %d", attempts)
+	}
+}
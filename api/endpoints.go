@@ -0,0 +1,172 @@
+package api
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WithEndpoints sets an ordered list of API base URLs, e.g. one per region.
+//
+// Submissions go to the first healthy endpoint and fail over to the next one
+// on connection errors or HTTP 5xx responses. An endpoint that fails is
+// considered unhealthy for the cooldown set with WithEndpointCooldown
+// (30 seconds by default). Polling, cancellation and other requests about a
+// task stick to the endpoint that accepted it.
+//
+// Example:
+//
+//	client := api.NewClient(api.WithEndpoints(
+//	    "https://api.wavespeed.ai",
+//	    "https://api-eu.example.com",
+//	))
+func WithEndpoints(baseURLs ...string) ClientOption {
+	return func(c *Client) {
+		cooldown := 30 * time.Second
+		if c.endpoints != nil {
+			cooldown = c.endpoints.cooldown
+		}
+		set := &endpointSet{cooldown: cooldown}
+		for _, baseURL := range baseURLs {
+			if baseURL = strings.TrimRight(baseURL, "/"); baseURL != "" {
+				set.endpoints = append(set.endpoints, &endpoint{url: baseURL})
+			}
+		}
+		if len(set.endpoints) > 0 {
+			c.baseURL = set.endpoints[0].url
+		}
+		c.endpoints = set
+	}
+}
+
+// WithEndpointCooldown sets how long a failing endpoint is skipped.
+func WithEndpointCooldown(cooldown time.Duration) ClientOption {
+	return func(c *Client) {
+		if c.endpoints == nil {
+			c.endpoints = &endpointSet{}
+		}
+		c.endpoints.cooldown = cooldown
+	}
+}
+
+// EndpointStatus reports the health of an endpoint configured with WithEndpoints.
+type EndpointStatus struct {
+	URL       string
+	Healthy   bool
+	Failures  int
+	LastError string
+}
+
+// Endpoints returns the health of each configured endpoint, in configuration
+// order. It returns the base URL as a single healthy endpoint when
+// WithEndpoints is not used.
+func (c *Client) Endpoints() []EndpointStatus {
+	if c.endpoints.empty() {
+		return []EndpointStatus{{URL: c.baseURL, Healthy: true}}
+	}
+
+	c.endpoints.mu.Lock()
+	defer c.endpoints.mu.Unlock()
+	now := time.Now()
+	statuses := make([]EndpointStatus, len(c.endpoints.endpoints))
+	for i, e := range c.endpoints.endpoints {
+		statuses[i] = EndpointStatus{
+			URL:       e.url,
+			Healthy:   !now.Before(e.unhealthyUntil),
+			Failures:  e.failures,
+			LastError: e.lastError,
+		}
+	}
+	return statuses
+}
+
+type endpoint struct {
+	url            string
+	failures       int
+	lastError      string
+	unhealthyUntil time.Time
+}
+
+// endpointSet tracks the health of the configured endpoints.
+type endpointSet struct {
+	mu        sync.Mutex
+	endpoints []*endpoint
+	cooldown  time.Duration
+}
+
+func (s *endpointSet) empty() bool {
+	return s == nil || len(s.endpoints) == 0
+}
+
+// order returns healthy endpoints in configuration order, followed by the
+// unhealthy ones, soonest to recover first.
+func (s *endpointSet) order() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	var healthy, unhealthy []*endpoint
+	for _, e := range s.endpoints {
+		if now.Before(e.unhealthyUntil) {
+			unhealthy = append(unhealthy, e)
+		} else {
+			healthy = append(healthy, e)
+		}
+	}
+	sort.SliceStable(unhealthy, func(i, j int) bool {
+		return unhealthy[i].unhealthyUntil.Before(unhealthy[j].unhealthyUntil)
+	})
+
+	urls := make([]string, 0, len(s.endpoints))
+	for _, e := range append(healthy, unhealthy...) {
+		urls = append(urls, e.url)
+	}
+	return urls
+}
+
+func (s *endpointSet) failure(baseURL string, err error) {
+	if s.empty() {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, e := range s.endpoints {
+		if e.url == baseURL {
+			e.failures++
+			e.lastError = err.Error()
+			e.unhealthyUntil = time.Now().Add(s.cooldown)
+		}
+	}
+}
+
+func (s *endpointSet) success(baseURL string) {
+	if s.empty() {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, e := range s.endpoints {
+		if e.url == baseURL {
+			e.failures = 0
+			e.unhealthyUntil = time.Time{}
+		}
+	}
+}
+
+// endpointOrder returns the base URLs to try for a new submission.
+func (c *Client) endpointOrder() []string {
+	if c.endpoints.empty() {
+		return []string{c.baseURL}
+	}
+	return c.endpoints.order()
+}
+
+// taskBaseURL returns the base URL for requests about taskID: the endpoint
+// that accepted it, or the preferred endpoint for tasks from elsewhere.
+func (c *Client) taskBaseURL(taskID string) string {
+	if route := c.route(taskID); route != nil && route.baseURL != "" {
+		return route.baseURL
+	}
+	return c.endpointOrder()[0]
+}
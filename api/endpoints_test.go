@@ -0,0 +1,103 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestEndpointsFailoverOn5xxAndStickyPolling(t *testing.T) {
+	primaryHits := 0
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		primaryHits++
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte("Service Unavailable"))
+	}))
+	defer primary.Close()
+
+	secondaryMux := http.NewServeMux()
+	secondaryMux.HandleFunc("/api/v3/wavespeed-ai/z-image/turbo", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"code":200,"data":{"id":"req-123"}}`))
+	})
+	secondaryMux.HandleFunc("/api/v3/predictions/req-123/result", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"code":200,"data":{"status":"completed","outputs":["https://example.com/out.png"]}}`))
+	})
+	secondary := httptest.NewServer(secondaryMux)
+	defer secondary.Close()
+
+	client := NewClient(WithAPIKey("test-key"), WithEndpoints(primary.URL, secondary.URL+"/"))
+	result, err := client.Run("wavespeed-ai/z-image/turbo", map[string]any{"prompt": "test"}, WithPollInterval(0.01))
+	if err != nil {
+		t.Fatalf("run error: %v", err)
+	}
+	if outputs := result["outputs"].([]any); len(outputs) != 1 {
+		t.Errorf("unexpected outputs: %+v", result)
+	}
+	if primaryHits != 1 {
+		t.Errorf("expected a single submission to the failing endpoint and no polling there, got %d hits", primaryHits)
+	}
+
+	statuses := client.Endpoints()
+	if len(statuses) != 2 || statuses[0].Healthy || statuses[0].Failures != 1 || !statuses[1].Healthy {
+		t.Errorf("unexpected endpoint health: %+v", statuses)
+	}
+	if !strings.Contains(statuses[0].LastError, "HTTP 503") {
+		t.Errorf("expected last error to be recorded, got %q", statuses[0].LastError)
+	}
+
+	// The unhealthy endpoint is skipped for new submissions.
+	if _, err := client.Submit("wavespeed-ai/z-image/turbo", nil); err != nil {
+		t.Fatalf("submit error: %v", err)
+	}
+	if primaryHits != 1 {
+		t.Errorf("expected unhealthy endpoint to be skipped, got %d hits", primaryHits)
+	}
+}
+
+func TestEndpointsFailoverOnConnectionError(t *testing.T) {
+	down := httptest.NewServer(http.NotFoundHandler())
+	downURL := down.URL
+	down.Close()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/wavespeed-ai/z-image/turbo", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"code":200,"data":{"id":"req-123"}}`))
+	})
+	mux.HandleFunc("/api/v3/predictions/req-123/cancel", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"code":200}`))
+	})
+	up := httptest.NewServer(mux)
+	defer up.Close()
+
+	client := NewClient(WithAPIKey("test-key"), WithEndpoints(downURL, up.URL), WithMaxConnectionRetries(0))
+	taskID, err := client.Submit("wavespeed-ai/z-image/turbo", nil)
+	if err != nil {
+		t.Fatalf("submit error: %v", err)
+	}
+	if err := client.Cancel(taskID); err != nil {
+		t.Errorf("expected cancel to go to the accepting endpoint, got %v", err)
+	}
+}
+
+func TestEndpointsAllFailing(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("Internal Server Error"))
+	}))
+	defer failing.Close()
+
+	client := NewClient(WithAPIKey("test-key"), WithEndpoints(failing.URL, failing.URL+"/v2"))
+	_, err := client.Submit("wavespeed-ai/z-image/turbo", nil)
+	if err == nil || !strings.Contains(err.Error(), "HTTP 500") {
+		t.Errorf("expected HTTP 500 error, got %v", err)
+	}
+}
+
+func TestWithBaseURLClearsEndpoints(t *testing.T) {
+	client := NewClient(WithEndpoints("https://a.example.com", "https://b.example.com"), WithBaseURL("https://c.example.com/"))
+	statuses := client.Endpoints()
+	if len(statuses) != 1 || statuses[0].URL != "https://c.example.com" {
+		t.Errorf("expected single base URL endpoint, got %+v", statuses)
+	}
+}
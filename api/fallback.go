@@ -0,0 +1,120 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+)
+
+// InputAdapter rewrites an input for a fallback model whose parameters differ
+// from the primary model's, e.g. renaming "size" to "aspect_ratio".
+type InputAdapter func(input map[string]any) (map[string]any, error)
+
+// WithFallbackModels makes Run, RunAs and RunNoThrow try the given models in
+// order when the model before them fails with a retryable error (a timeout,
+// connection error, HTTP 5xx or 429) or its circuit breaker is open. Failed
+// predictions and invalid inputs do not fall back.
+//
+// Each model gets the full timeout and task-level retries. The model that
+// served the request is reported in RunDetail.Model and Prediction.Model.
+//
+// Example:
+//
+//	result := client.RunNoThrow("wavespeed-ai/flux-pro", input,
+//	    api.WithFallbackModels("wavespeed-ai/flux-dev", "wavespeed-ai/z-image/turbo"),
+//	    api.WithInputAdapter("wavespeed-ai/z-image/turbo", func(in map[string]any) (map[string]any, error) {
+//	        out := map[string]any{"prompt": in["prompt"]}
+//	        return out, nil
+//	    }),
+//	)
+//	fmt.Println("served by", result.Detail.Model)
+func WithFallbackModels(models ...string) RunOption {
+	return func(o *RunOptions) {
+		o.FallbackModels = append(o.FallbackModels, models...)
+	}
+}
+
+// WithInputAdapter sets the adapter used to build the input for model when it
+// is tried as a fallback. The primary model always gets the input unchanged.
+func WithInputAdapter(model string, adapt InputAdapter) RunOption {
+	return func(o *RunOptions) {
+		if o.InputAdapters == nil {
+			o.InputAdapters = make(map[string]InputAdapter)
+		}
+		o.InputAdapters[model] = adapt
+	}
+}
+
+// modelChain returns model followed by its fallbacks, without duplicates.
+func (o *RunOptions) modelChain(model string) []string {
+	chain := []string{model}
+	seen := map[string]bool{model: true}
+	for _, m := range o.FallbackModels {
+		if m != "" && !seen[m] {
+			seen[m] = true
+			chain = append(chain, m)
+		}
+	}
+	return chain
+}
+
+// fallbackAttempt returns the input and options for trying model at position
+// i of its fallback chain. Position 0 is the primary model.
+func (o *RunOptions) fallbackAttempt(i int, model string, input map[string]any) (map[string]any, *RunOptions, error) {
+	if i == 0 {
+		return input, o, nil
+	}
+	if adapt := o.InputAdapters[model]; adapt != nil {
+		adapted, err := adapt(input)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to adapt input for model %s: %w", model, err)
+		}
+		input = adapted
+	}
+	if o.IdempotencyKey == "" {
+		return input, o, nil
+	}
+
+	// Each model needs its own key, or the primary model's task would be reused.
+	options := *o
+	options.IdempotencyKey = o.IdempotencyKey + ":" + model
+	return input, &options, nil
+}
+
+// isFallbackError reports whether a failure of one model should be retried on
+// the next model of a fallback chain.
+func (c *Client) isFallbackError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, ErrCircuitOpen) {
+		return true
+	}
+	var timeout *timeoutError
+	if errors.As(err, &timeout) {
+		return true
+	}
+	var status *statusError
+	if errors.As(err, &status) {
+		return status.code >= 500 || status.code == http.StatusTooManyRequests
+	}
+	// Transport errors: refused or dropped connections and request timeouts.
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+func (c *Client) logFallback(model string, err error, next string) {
+	c.logf("Model %s failed: %v\n", model, err)
+	c.logf("Falling back to %s...\n", next)
+}
+
+// logf writes a retry or failover message to the client's log output.
+func (c *Client) logf(format string, args ...any) {
+	w := c.logOutput
+	if w == nil {
+		w = os.Stderr
+	}
+	fmt.Fprintf(w, format, args...)
+}
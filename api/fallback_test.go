@@ -0,0 +1,175 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func newFallbackServer(t *testing.T, primaryStatus int) (*httptest.Server, func() map[string][]map[string]any) {
+	t.Helper()
+	var mu sync.Mutex
+	submitted := map[string][]map[string]any{}
+	record := func(model string, r *http.Request) {
+		var input map[string]any
+		json.NewDecoder(r.Body).Decode(&input)
+		if key := r.Header.Get("Idempotency-Key"); key != "" {
+			input["_key"] = key
+		}
+		mu.Lock()
+		submitted[model] = append(submitted[model], input)
+		mu.Unlock()
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/premium", func(w http.ResponseWriter, r *http.Request) {
+		record("premium", r)
+		w.WriteHeader(primaryStatus)
+		w.Write([]byte(`{"code":503,"message":"model overloaded"}`))
+	})
+	mux.HandleFunc("/api/v3/cheap", func(w http.ResponseWriter, r *http.Request) {
+		record("cheap", r)
+		w.Write([]byte(`{"code":200,"data":{"id":"req-cheap"}}`))
+	})
+	mux.HandleFunc("/api/v3/predictions/req-cheap/result", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"code":200,"data":{"status":"completed","outputs":["https://example.com/cheap.png"]}}`))
+	})
+	mux.HandleFunc("/api/v3/broken", func(w http.ResponseWriter, r *http.Request) {
+		record("broken", r)
+		w.Write([]byte(`{"code":200,"data":{"id":"req-broken"}}`))
+	})
+	mux.HandleFunc("/api/v3/predictions/req-broken/result", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"code":200,"data":{"status":"failed","error":"connection timeout in model worker"}}`))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return server, func() map[string][]map[string]any {
+		mu.Lock()
+		defer mu.Unlock()
+		return submitted
+	}
+}
+
+func TestRunFallsBackToNextModel(t *testing.T) {
+	server, submitted := newFallbackServer(t, http.StatusServiceUnavailable)
+	client := NewClient(WithAPIKey("test-key"), WithBaseURL(server.URL), WithMaxConnectionRetries(0))
+
+	adapter := WithInputAdapter("cheap", func(in map[string]any) (map[string]any, error) {
+		return map[string]any{"prompt": in["prompt"], "adapted": true}, nil
+	})
+	result := client.RunNoThrow("premium", map[string]any{"prompt": "Cat", "size": "2048*2048"},
+		WithFallbackModels("cheap"), adapter, WithPollInterval(0.01), WithIdempotencyKey("job-1"))
+	if result.Outputs == nil {
+		t.Fatalf("expected fallback to succeed, got %+v", result.Detail)
+	}
+	if result.Detail.Model != "cheap" || result.Detail.TaskID != "req-cheap" {
+		t.Errorf("expected detail of the serving model, got %+v", result.Detail)
+	}
+
+	cheap := submitted()["cheap"]
+	if len(submitted()["premium"]) != 1 || len(cheap) != 1 {
+		t.Fatalf("expected one submission per model, got %v", submitted())
+	}
+	if cheap[0]["adapted"] != true || cheap[0]["size"] != nil {
+		t.Errorf("expected adapted input, got %v", cheap[0])
+	}
+	if cheap[0]["_key"] != "job-1:cheap" {
+		t.Errorf("expected a per-model idempotency key, got %v", cheap[0]["_key"])
+	}
+
+	out, pred, err := RunAs[[]string](context.Background(), client, "premium", map[string]any{"prompt": "Cat"},
+		WithFallbackModels("cheap"), WithPollInterval(0.01))
+	if err != nil {
+		t.Fatalf("run error: %v", err)
+	}
+	if pred.Model != "cheap" || len(out) != 1 {
+		t.Errorf("unexpected prediction: %+v, %v", pred, out)
+	}
+}
+
+func TestRunDoesNotFallBackOnClientErrors(t *testing.T) {
+	server, submitted := newFallbackServer(t, http.StatusBadRequest)
+	client := NewClient(WithAPIKey("test-key"), WithBaseURL(server.URL), WithMaxConnectionRetries(0))
+
+	_, err := client.Run("premium", map[string]any{"prompt": "Cat"}, WithFallbackModels("cheap"))
+	if err == nil || !strings.Contains(err.Error(), "HTTP 400") {
+		t.Fatalf("expected the primary model's error, got %v", err)
+	}
+	if len(submitted()["cheap"]) != 0 {
+		t.Errorf("expected no fallback submission, got %v", submitted()["cheap"])
+	}
+
+	result := client.RunNoThrow("premium", map[string]any{"prompt": "Cat"}, WithFallbackModels("cheap"))
+	if result.Outputs != nil || result.Detail.Model != "premium" {
+		t.Errorf("expected failure reported for premium, got %+v", result.Detail)
+	}
+}
+
+func TestRunDoesNotFallBackOnFailedPredictions(t *testing.T) {
+	server, submitted := newFallbackServer(t, http.StatusServiceUnavailable)
+	client := NewClient(WithAPIKey("test-key"), WithBaseURL(server.URL), WithMaxConnectionRetries(0))
+
+	// The failure message mentions a timeout, but the prediction itself failed.
+	_, err := client.Run("broken", map[string]any{"prompt": "Cat"}, WithFallbackModels("cheap"), WithPollInterval(0.01))
+	if err == nil || !strings.Contains(err.Error(), "prediction failed") {
+		t.Fatalf("expected the primary model's failure, got %v", err)
+	}
+
+	result := client.RunNoThrow("broken", map[string]any{"prompt": "Cat"}, WithFallbackModels("cheap"), WithPollInterval(0.01))
+	if result.Outputs != nil || result.Detail.Model != "broken" {
+		t.Errorf("expected failure reported for broken, got %+v", result.Detail)
+	}
+	if len(submitted()["cheap"]) != 0 {
+		t.Errorf("expected no fallback submission, got %v", submitted()["cheap"])
+	}
+}
+
+func TestInputAdapterSkipsPrimaryModel(t *testing.T) {
+	server, submitted := newFallbackServer(t, http.StatusServiceUnavailable)
+	client := NewClient(WithAPIKey("test-key"), WithBaseURL(server.URL), WithMaxConnectionRetries(0))
+
+	adapt := func(in map[string]any) (map[string]any, error) {
+		return map[string]any{"prompt": in["prompt"], "adapted": true}, nil
+	}
+	result := client.RunNoThrow("premium", map[string]any{"prompt": "Cat"},
+		WithFallbackModels("cheap"), WithInputAdapter("premium", adapt), WithInputAdapter("cheap", adapt),
+		WithPollInterval(0.01))
+	if result.Outputs == nil {
+		t.Fatalf("expected fallback to succeed, got %+v", result.Detail)
+	}
+
+	premium, cheap := submitted()["premium"], submitted()["cheap"]
+	if len(premium) != 1 || premium[0]["adapted"] != nil {
+		t.Errorf("expected the primary model to get the input unchanged, got %v", premium)
+	}
+	if len(cheap) != 1 || cheap[0]["adapted"] != true {
+		t.Errorf("expected adapted input for the fallback model, got %v", cheap)
+	}
+}
+
+func TestRunFallbackAdapterError(t *testing.T) {
+	server, _ := newFallbackServer(t, http.StatusServiceUnavailable)
+	client := NewClient(WithAPIKey("test-key"), WithBaseURL(server.URL), WithMaxConnectionRetries(0))
+
+	adapter := WithInputAdapter("cheap", func(in map[string]any) (map[string]any, error) {
+		return nil, errors.New("prompt is required")
+	})
+	_, err := client.Run("premium", map[string]any{}, WithFallbackModels("cheap"), adapter)
+	if err == nil || !strings.Contains(err.Error(), "failed to adapt input for model cheap") {
+		t.Errorf("expected adapter error, got %v", err)
+	}
+}
+
+func TestModelChain(t *testing.T) {
+	options := &RunOptions{}
+	WithFallbackModels("b", "a", "", "b", "c")(options)
+	if chain := options.modelChain("a"); strings.Join(chain, ",") != "a,b,c" {
+		t.Errorf("unexpected chain: %v", chain)
+	}
+}
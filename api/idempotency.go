@@ -0,0 +1,123 @@
+package api
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// idempotencyTTL is how long a key keeps returning the task it submitted.
+const idempotencyTTL = 24 * time.Hour
+
+// WithIdempotencyKey makes resubmitting the same task safe. The key is sent to
+// the API in the Idempotency-Key header so that connection retries of a
+// submission the server already accepted do not create a second prediction.
+// The client also remembers the task ID submitted under the key, and so does
+// the task store if one is set with WithTaskStore: a later Run or Submit with
+// the same key returns that task instead of submitting again. Concurrent
+// submissions with the same key wait for the first one and share its task.
+// Keys are forgotten 24 hours after their task was submitted.
+//
+// Example:
+//
+//	output, err := client.Run(model, input, api.WithIdempotencyKey("order-1234-thumbnail"))
+func WithIdempotencyKey(key string) RunOption {
+	return func(o *RunOptions) {
+		o.IdempotencyKey = key
+	}
+}
+
+// idempotentEntry is a key that submitted, or is submitting, a task.
+type idempotentEntry struct {
+	taskID string
+	at     time.Time
+	// done is closed once the submission holding the key finished.
+	done chan struct{}
+}
+
+// reserveIdempotent returns the task already submitted with key, waiting for
+// a submission in progress with the same key until ctx is done. If there is
+// none, it reserves key for the caller, who must call the returned function
+// once its submission is over. A failed submission releases the key for the
+// next caller.
+func (c *Client) reserveIdempotent(ctx context.Context, key string) (string, func(), error) {
+	var entry *idempotentEntry
+	for {
+		c.routesMu.Lock()
+		now := time.Now()
+		c.expireIdempotent(now)
+		entry = c.idempotent[key]
+		if entry == nil {
+			entry = &idempotentEntry{at: now, done: make(chan struct{})}
+			if c.idempotent == nil {
+				c.idempotent = make(map[string]*idempotentEntry)
+			}
+			c.idempotent[key] = entry
+			c.routesMu.Unlock()
+			break
+		}
+		c.routesMu.Unlock()
+
+		select {
+		case <-entry.done:
+		case <-ctx.Done():
+			return "", nil, ctx.Err()
+		}
+		c.routesMu.Lock()
+		taskID := entry.taskID
+		c.routesMu.Unlock()
+		if taskID != "" {
+			return taskID, nil, nil
+		}
+	}
+
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			c.routesMu.Lock()
+			defer c.routesMu.Unlock()
+			if entry.taskID == "" && c.idempotent[key] == entry {
+				delete(c.idempotent, key)
+			}
+			close(entry.done)
+		})
+	}
+
+	if c.store != nil {
+		record, err := c.store.LoadByIdempotencyKey(key)
+		if err == nil && record != nil && time.Since(record.SubmittedAt) < idempotencyTTL {
+			c.rememberIdempotent(key, record.TaskID)
+			release()
+			return record.TaskID, nil, nil
+		}
+	}
+	return "", release, nil
+}
+
+// expireIdempotent forgets keys whose task was submitted longer than
+// idempotencyTTL ago. It must be called with routesMu held.
+func (c *Client) expireIdempotent(now time.Time) {
+	if now.Sub(c.idempotentSwept) < time.Minute {
+		return
+	}
+	c.idempotentSwept = now
+	for key, entry := range c.idempotent {
+		if entry.taskID != "" && now.Sub(entry.at) >= idempotencyTTL {
+			delete(c.idempotent, key)
+		}
+	}
+}
+
+// rememberIdempotent records that key submitted taskID. The key must have
+// been reserved with reserveIdempotent.
+func (c *Client) rememberIdempotent(key, taskID string) {
+	if key == "" || taskID == "" {
+		return
+	}
+	c.routesMu.Lock()
+	defer c.routesMu.Unlock()
+	if entry := c.idempotent[key]; entry != nil {
+		entry.taskID = taskID
+		entry.at = time.Now()
+	}
+}
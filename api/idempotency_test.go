@@ -0,0 +1,196 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newIdempotencyServer(t *testing.T, submits *int32, keys *[]string) *httptest.Server {
+	var mu sync.Mutex
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/wavespeed-ai/z-image/turbo", func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(submits, 1)
+		mu.Lock()
+		*keys = append(*keys, r.Header.Get("Idempotency-Key"))
+		mu.Unlock()
+		if n == 1 {
+			// The first submission is accepted but the response is too slow.
+			time.Sleep(300 * time.Millisecond)
+		}
+		w.Write([]byte(`{"code":200,"data":{"id":"req-123","status":"created"}}`))
+	})
+	mux.HandleFunc("/api/v3/predictions/req-123/result", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"code":200,"data":{"id":"req-123","status":"completed","outputs":["https://example.com/out.png"]}}`))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestIdempotencyKeySentOnRetriesAndReused(t *testing.T) {
+	var submits int32
+	var keys []string
+	server := newIdempotencyServer(t, &submits, &keys)
+
+	client := NewClient(
+		WithAPIKey("test-key"),
+		WithBaseURL(server.URL),
+		WithConnectionTimeout(0.1),
+		WithMaxConnectionRetries(1),
+		WithRetryInterval(0.01),
+	)
+	taskID, err := client.Submit("wavespeed-ai/z-image/turbo", nil, WithIdempotencyKey("order-1"))
+	if err != nil {
+		t.Fatalf("submit error: %v", err)
+	}
+	if len(keys) != 2 || keys[0] != "order-1" || keys[1] != "order-1" {
+		t.Errorf("expected the key on every connection retry, got %v", keys)
+	}
+
+	again, err := client.Submit("wavespeed-ai/z-image/turbo", nil, WithIdempotencyKey("order-1"))
+	if err != nil || again != taskID {
+		t.Errorf("expected the existing task %s, got %s, %v", taskID, again, err)
+	}
+
+	// Sync mode runs reuse the task too, waiting on it instead of resubmitting.
+	output, err := client.Run("wavespeed-ai/z-image/turbo", nil, WithIdempotencyKey("order-1"), WithSyncMode(true), WithPollInterval(0.01))
+	if err != nil || len(output["outputs"].([]any)) != 1 {
+		t.Errorf("unexpected run result: %v, %v", output, err)
+	}
+	if n := atomic.LoadInt32(&submits); n != 2 {
+		t.Errorf("expected no new submissions for a reused key, got %d", n)
+	}
+
+	if _, err := client.Submit("wavespeed-ai/z-image/turbo", nil, WithIdempotencyKey("order-2")); err != nil {
+		t.Fatalf("submit error: %v", err)
+	}
+	if n := atomic.LoadInt32(&submits); n != 3 {
+		t.Errorf("expected a new key to submit, got %d submissions", n)
+	}
+}
+
+func TestIdempotencyKeyFromTaskStore(t *testing.T) {
+	var submits int32
+	var keys []string
+	server := newIdempotencyServer(t, &submits, &keys)
+	atomic.StoreInt32(&submits, 1) // skip the slow first response
+
+	store := NewMemoryTaskStore()
+	client := NewClient(WithAPIKey("test-key"), WithBaseURL(server.URL), WithTaskStore(store))
+	taskID, err := client.Submit("wavespeed-ai/z-image/turbo", nil, WithIdempotencyKey("order-1"))
+	if err != nil {
+		t.Fatalf("submit error: %v", err)
+	}
+	if record, _ := store.LoadByIdempotencyKey("order-1"); record == nil || record.TaskID != taskID {
+		t.Fatalf("expected the key in the task store, got %+v", record)
+	}
+
+	// A new client, as after a restart, finds the task through the store.
+	client = NewClient(WithAPIKey("test-key"), WithBaseURL(server.URL), WithTaskStore(store))
+	again, err := client.Submit("wavespeed-ai/z-image/turbo", nil, WithIdempotencyKey("order-1"))
+	if err != nil || again != taskID {
+		t.Errorf("expected the existing task %s, got %s, %v", taskID, again, err)
+	}
+	if n := atomic.LoadInt32(&submits); n != 2 {
+		t.Errorf("expected a single submission, got %d", n-1)
+	}
+}
+
+func TestIdempotencyKeyConcurrentSubmissions(t *testing.T) {
+	var submits int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/wavespeed-ai/z-image/turbo", func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&submits, 1)
+		time.Sleep(50 * time.Millisecond)
+		if n == 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":400,"message":"bad input"}`))
+			return
+		}
+		w.Write([]byte(fmt.Sprintf(`{"code":200,"data":{"id":"req-%d"}}`, n)))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := NewClient(WithAPIKey("test-key"), WithBaseURL(server.URL), WithClientMaxRetries(0))
+	if _, err := client.Submit("wavespeed-ai/z-image/turbo", nil, WithIdempotencyKey("order-1")); err == nil {
+		t.Fatal("expected the first submission to fail")
+	}
+
+	// A failed submission releases the key; of the concurrent ones only the
+	// first submits and the others share its task.
+	var wg sync.WaitGroup
+	ids := make([]string, 5)
+	for i := range ids {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			id, err := client.Submit("wavespeed-ai/z-image/turbo", nil, WithIdempotencyKey("order-1"))
+			if err != nil {
+				t.Errorf("submit error: %v", err)
+			}
+			ids[i] = id
+		}(i)
+	}
+	wg.Wait()
+
+	if n := atomic.LoadInt32(&submits); n != 2 {
+		t.Errorf("expected a single submission after the failed one, got %d", n-1)
+	}
+	for _, id := range ids {
+		if id != "req-2" {
+			t.Errorf("expected every submission to share req-2, got %v", ids)
+			break
+		}
+	}
+
+	// Keys are forgotten once they expire.
+	client.routesMu.Lock()
+	client.idempotent["order-1"].at = time.Now().Add(-idempotencyTTL)
+	client.idempotentSwept = time.Time{}
+	client.routesMu.Unlock()
+	if id, err := client.Submit("wavespeed-ai/z-image/turbo", nil, WithIdempotencyKey("order-1")); err != nil || id != "req-3" {
+		t.Errorf("expected an expired key to submit again, got %s, %v", id, err)
+	}
+	if n := len(client.idempotent); n != 1 {
+		t.Errorf("expected 1 remembered key, got %d", n)
+	}
+}
+
+func TestIdempotencyKeyWaitRespectsContext(t *testing.T) {
+	release := make(chan struct{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/wavespeed-ai/z-image/turbo", func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.Write([]byte(`{"code":200,"data":{"id":"req-1"}}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	defer close(release)
+
+	client := NewClient(WithAPIKey("test-key"), WithBaseURL(server.URL))
+	go client.Submit("wavespeed-ai/z-image/turbo", nil, WithIdempotencyKey("order-1"))
+	for {
+		client.routesMu.Lock()
+		reserved := client.idempotent["order-1"] != nil
+		client.routesMu.Unlock()
+		if reserved {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := client.RunContext(ctx, "wavespeed-ai/z-image/turbo", nil, WithIdempotencyKey("order-1"))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the wait for the first submission to stop at the deadline, got %v", err)
+	}
+}
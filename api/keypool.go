@@ -0,0 +1,303 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// KeySelection is the strategy a key pool uses to pick a key for each task.
+type KeySelection int
+
+const (
+	// RoundRobin cycles through the keys in order.
+	RoundRobin KeySelection = iota
+	// LeastInFlight picks the key with the fewest unfinished tasks.
+	LeastInFlight
+)
+
+// WithAPIKeys spreads tasks across several API keys, e.g. one per account, to
+// stay under per-account concurrency limits. A key is picked for each task
+// and used for all requests about that task, including polling. Keys that
+// receive HTTP 429 or 401 are taken out of rotation for the cooldown set with
+// WithKeyCooldown (30 seconds by default).
+//
+// With WithTaskStore, the store records which key submitted each task, so a
+// new client with the same keys, e.g. after a restart, polls the task with
+// that key. Tasks this client neither submitted nor finds in its store, such
+// as an Attach on a task ID from elsewhere, are polled with the next key in
+// rotation, which fails if the task belongs to another account.
+//
+// Example:
+//
+//	client := api.NewClient(
+//	    api.WithAPIKeys("key-team-a", "key-team-b", "key-team-c"),
+//	    api.WithKeySelection(api.LeastInFlight),
+//	)
+func WithAPIKeys(keys ...string) ClientOption {
+	return func(c *Client) {
+		pool := &keyPool{cooldown: 30 * time.Second}
+		if c.keys != nil {
+			pool.selection = c.keys.selection
+			pool.cooldown = c.keys.cooldown
+		}
+		for _, key := range keys {
+			if key != "" {
+				pool.keys = append(pool.keys, &pooledKey{key: key})
+			}
+		}
+		c.keys = pool
+	}
+}
+
+// WithKeySelection sets how WithAPIKeys picks a key for each task.
+func WithKeySelection(selection KeySelection) ClientOption {
+	return func(c *Client) {
+		if c.keys == nil {
+			c.keys = &keyPool{cooldown: 30 * time.Second}
+		}
+		c.keys.selection = selection
+	}
+}
+
+// WithKeyCooldown sets how long a key stays out of rotation after HTTP 429 or 401.
+func WithKeyCooldown(cooldown time.Duration) ClientOption {
+	return func(c *Client) {
+		if c.keys == nil {
+			c.keys = &keyPool{}
+		}
+		c.keys.cooldown = cooldown
+	}
+}
+
+type pooledKey struct {
+	key           string
+	inFlight      int
+	disabledUntil time.Time
+}
+
+// keyPool hands out API keys to tasks. A nil or empty pool hands out "",
+// meaning the client's single key or credentials provider is used.
+type keyPool struct {
+	mu        sync.Mutex
+	keys      []*pooledKey
+	next      int
+	selection KeySelection
+	cooldown  time.Duration
+}
+
+// acquire picks a key for a new task and counts it as in flight. When every
+// key is cooling down, the one that recovers first is used.
+func (p *keyPool) acquire() string {
+	if p.empty() {
+		return ""
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	var chosen *pooledKey
+	for i := 0; i < len(p.keys); i++ {
+		k := p.keys[(p.next+i)%len(p.keys)]
+		if now.Before(k.disabledUntil) {
+			continue
+		}
+		if chosen == nil {
+			chosen = k
+			if p.selection == RoundRobin {
+				break
+			}
+		} else if k.inFlight < chosen.inFlight {
+			chosen = k
+		}
+	}
+
+	if chosen == nil {
+		for _, k := range p.keys {
+			if chosen == nil || k.disabledUntil.Before(chosen.disabledUntil) {
+				chosen = k
+			}
+		}
+	}
+
+	for i, k := range p.keys {
+		if k == chosen {
+			p.next = (i + 1) % len(p.keys)
+		}
+	}
+	chosen.inFlight++
+	return chosen.key
+}
+
+func (p *keyPool) empty() bool {
+	return p == nil || len(p.keys) == 0
+}
+
+// release marks a task using key as finished.
+func (p *keyPool) release(key string) {
+	if k := p.find(key); k != nil {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if k.inFlight > 0 {
+			k.inFlight--
+		}
+	}
+}
+
+// penalize takes key out of rotation for the cooldown period.
+func (p *keyPool) penalize(key string) {
+	if k := p.find(key); k != nil {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		k.disabledUntil = time.Now().Add(p.cooldown)
+	}
+}
+
+// byID returns the pooled key whose keyID is id, or "" if there is none.
+func (p *keyPool) byID(id string) string {
+	if p == nil || id == "" {
+		return ""
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, k := range p.keys {
+		if keyID(k.key) == id {
+			return k.key
+		}
+	}
+	return ""
+}
+
+// keyID returns a fingerprint of apiKey that can be stored without revealing
+// the key, or "" for an empty key.
+func keyID(apiKey string) string {
+	if apiKey == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:8])
+}
+
+func (p *keyPool) find(key string) *pooledKey {
+	if p == nil || key == "" {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, k := range p.keys {
+		if k.key == key {
+			return k
+		}
+	}
+	return nil
+}
+
+// taskRoute records how a task was submitted so that follow-up requests about
+// it go through the same API key and endpoint.
+type taskRoute struct {
+	apiKey   string
+	baseURL  string
+	inFlight bool
+}
+
+// bindTask remembers the key and endpoint that submitted taskID.
+func (c *Client) bindTask(taskID, apiKey, baseURL string) {
+	if apiKey == "" && c.endpoints.empty() {
+		return
+	}
+	c.routesMu.Lock()
+	defer c.routesMu.Unlock()
+	if c.routes == nil {
+		c.routes = make(map[string]*taskRoute)
+	}
+	c.routes[taskID] = &taskRoute{apiKey: apiKey, baseURL: baseURL, inFlight: apiKey != ""}
+}
+
+// rebindTask restores the route of a task submitted by an earlier client
+// from the key recorded in the task store. It returns nil if the store has no
+// record of the task or its key is not in the pool.
+func (c *Client) rebindTask(taskID string) *taskRoute {
+	if c.store == nil {
+		return nil
+	}
+	record, err := c.store.Load(taskID)
+	if err != nil || record == nil {
+		return nil
+	}
+	apiKey := c.keys.byID(record.KeyID)
+	if apiKey == "" {
+		return nil
+	}
+
+	c.routesMu.Lock()
+	defer c.routesMu.Unlock()
+	if route := c.routes[taskID]; route != nil {
+		return route
+	}
+	if c.routes == nil {
+		c.routes = make(map[string]*taskRoute)
+	}
+	route := &taskRoute{apiKey: apiKey}
+	c.routes[taskID] = route
+	return route
+}
+
+// route returns how taskID was submitted, or nil if this client did not submit it.
+func (c *Client) route(taskID string) *taskRoute {
+	c.routesMu.Lock()
+	defer c.routesMu.Unlock()
+	return c.routes[taskID]
+}
+
+// finishTask stops counting taskID as in flight. When forget is true, the
+// route is dropped as no further requests about the task are expected.
+func (c *Client) finishTask(taskID string, forget bool) {
+	c.routesMu.Lock()
+	route := c.routes[taskID]
+	if forget {
+		delete(c.routes, taskID)
+	}
+	release := route != nil && route.inFlight
+	if release {
+		route.inFlight = false
+	}
+	c.routesMu.Unlock()
+
+	if release {
+		c.keys.release(route.apiKey)
+	}
+}
+
+// headersWithKey returns request headers authenticated with apiKey, or with
+// the client's own key or credentials provider when apiKey is empty.
+func (c *Client) headersWithKey(apiKey string) (map[string]string, error) {
+	if apiKey == "" {
+		return c.getHeaders()
+	}
+	if c.configErr != nil {
+		return nil, c.configErr
+	}
+	return map[string]string{
+		"Content-Type":  "application/json",
+		"Authorization": "Bearer " + apiKey,
+	}, nil
+}
+
+// taskHeaders returns request headers for a follow-up request about taskID,
+// using the key that submitted it. Tasks this client did not submit use the
+// key recorded in the task store, or else the next key from the pool.
+func (c *Client) taskHeaders(taskID string) (map[string]string, error) {
+	if route := c.route(taskID); route != nil {
+		return c.headersWithKey(route.apiKey)
+	}
+	if c.keys.empty() {
+		return c.getHeaders()
+	}
+	if route := c.rebindTask(taskID); route != nil {
+		return c.headersWithKey(route.apiKey)
+	}
+	apiKey := c.keys.acquire()
+	c.keys.release(apiKey)
+	return c.headersWithKey(apiKey)
+}
@@ -0,0 +1,229 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestKeyPoolRoundRobinAndStickyPolling(t *testing.T) {
+	var mu sync.Mutex
+	submitKeys := map[string]string{}
+	var mismatches []string
+	next := 0
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/wavespeed-ai/z-image/turbo", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		next++
+		id := fmt.Sprintf("req-%d", next)
+		submitKeys[id] = r.Header.Get("Authorization")
+		w.Write([]byte(`{"code":200,"data":{"id":"` + id + `"}}`))
+	})
+	mux.HandleFunc("/api/v3/predictions/", func(w http.ResponseWriter, r *http.Request) {
+		id := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/v3/predictions/"), "/")[0]
+		mu.Lock()
+		if submitKeys[id] != r.Header.Get("Authorization") {
+			mismatches = append(mismatches, id)
+		}
+		mu.Unlock()
+		w.Write([]byte(`{"code":200,"data":{"status":"completed","outputs":[]}}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := NewClient(WithAPIKeys("key-a", "key-b", "key-c"), WithBaseURL(server.URL))
+	for i := 0; i < 6; i++ {
+		if _, err := client.Run("wavespeed-ai/z-image/turbo", nil, WithPollInterval(0.01)); err != nil {
+			t.Fatalf("run error: %v", err)
+		}
+	}
+
+	var used []string
+	for i := 1; i <= 6; i++ {
+		used = append(used, strings.TrimPrefix(submitKeys[fmt.Sprintf("req-%d", i)], "Bearer "))
+	}
+	if strings.Join(used, ",") != "key-a,key-b,key-c,key-a,key-b,key-c" {
+		t.Errorf("expected round-robin key usage, got %v", used)
+	}
+	if len(mismatches) > 0 {
+		t.Errorf("expected polling with the submitting key, mismatched tasks: %v", mismatches)
+	}
+	for _, k := range client.keys.keys {
+		if k.inFlight != 0 {
+			t.Errorf("expected no tasks in flight for %s, got %d", k.key, k.inFlight)
+		}
+	}
+}
+
+func TestKeyPoolCooldownAfterRateLimit(t *testing.T) {
+	var keys []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/wavespeed-ai/z-image/turbo", func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Authorization")
+		keys = append(keys, strings.TrimPrefix(key, "Bearer "))
+		if key == "Bearer key-a" {
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"code":429,"message":"too many requests"}`))
+			return
+		}
+		w.Write([]byte(`{"code":200,"data":{"id":"req-1","status":"completed","outputs":[]}}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := NewClient(WithAPIKeys("key-a", "key-b"), WithBaseURL(server.URL), WithRetryInterval(0.01))
+	_, err := client.Run("wavespeed-ai/z-image/turbo", nil, WithSyncMode(true), WithMaxRetries(1))
+	if err != nil {
+		t.Fatalf("run error: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := client.Run("wavespeed-ai/z-image/turbo", nil, WithSyncMode(true)); err != nil {
+			t.Fatalf("run error: %v", err)
+		}
+	}
+
+	if strings.Join(keys, ",") != "key-a,key-b,key-b,key-b" {
+		t.Errorf("expected key-a to be out of rotation after 429, got %v", keys)
+	}
+}
+
+func TestKeyPoolLeastInFlight(t *testing.T) {
+	pool := &keyPool{selection: LeastInFlight, cooldown: time.Minute}
+	for _, key := range []string{"key-a", "key-b", "key-c"} {
+		pool.keys = append(pool.keys, &pooledKey{key: key})
+	}
+
+	first := pool.acquire()
+	second := pool.acquire()
+	pool.release(first)
+	third := pool.acquire()
+	fourth := pool.acquire()
+
+	if first != "key-a" || second != "key-b" {
+		t.Fatalf("unexpected initial picks: %s, %s", first, second)
+	}
+	if third != "key-c" || fourth != "key-a" {
+		t.Errorf("expected idle keys to be picked first, got %s, %s", third, fourth)
+	}
+
+	pool.penalize("key-c")
+	pool.penalize("key-a")
+	pool.penalize("key-b")
+	if key := pool.acquire(); key != "key-c" {
+		t.Errorf("expected the key that recovers first when all are cooling down, got %s", key)
+	}
+}
+
+func TestKeyPoolForgetsFinishedTasks(t *testing.T) {
+	var mu sync.Mutex
+	next := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/wavespeed-ai/z-image/turbo", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		next++
+		w.Write([]byte(fmt.Sprintf(`{"code":200,"data":{"id":"req-%d"}}`, next)))
+	})
+	mux.HandleFunc("/api/v3/predictions/", func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/cancel") {
+			w.Write([]byte(`{"code":200}`))
+			return
+		}
+		w.Write([]byte(`{"code":200,"data":{"status":"completed","outputs":["a.png"]}}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := NewClient(WithAPIKeys("key-a", "key-b"), WithBaseURL(server.URL))
+	routes := func() int {
+		client.routesMu.Lock()
+		defer client.routesMu.Unlock()
+		return len(client.routes)
+	}
+
+	polled, err := client.Submit("wavespeed-ai/z-image/turbo", nil)
+	if err != nil {
+		t.Fatalf("submit error: %v", err)
+	}
+	cancelled, err := client.Submit("wavespeed-ai/z-image/turbo", nil)
+	if err != nil {
+		t.Fatalf("submit error: %v", err)
+	}
+	if n := routes(); n != 2 {
+		t.Fatalf("expected 2 routes after submitting, got %d", n)
+	}
+
+	if _, err := client.GetPrediction(polled); err != nil {
+		t.Fatalf("get prediction error: %v", err)
+	}
+	if err := client.Cancel(cancelled); err != nil {
+		t.Fatalf("cancel error: %v", err)
+	}
+	if n := routes(); n != 0 {
+		t.Errorf("expected finished tasks to be forgotten, got %d routes", n)
+	}
+	for _, k := range client.keys.keys {
+		if k.inFlight != 0 {
+			t.Errorf("expected no tasks in flight for %s, got %d", k.key, k.inFlight)
+		}
+	}
+}
+
+func TestKeyPoolRebindsStoredTasks(t *testing.T) {
+	var mu sync.Mutex
+	submitKeys := map[string]string{}
+	next := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/wavespeed-ai/z-image/turbo", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		next++
+		id := fmt.Sprintf("req-%d", next)
+		submitKeys[id] = r.Header.Get("Authorization")
+		w.Write([]byte(`{"code":200,"data":{"id":"` + id + `"}}`))
+	})
+	mux.HandleFunc("/api/v3/predictions/", func(w http.ResponseWriter, r *http.Request) {
+		id := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/v3/predictions/"), "/")[0]
+		mu.Lock()
+		owner := submitKeys[id]
+		mu.Unlock()
+		if owner != r.Header.Get("Authorization") {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code":404,"message":"task not found"}`))
+			return
+		}
+		w.Write([]byte(`{"code":200,"data":{"status":"completed","outputs":["a.png"]}}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	store := NewMemoryTaskStore()
+	client := NewClient(WithAPIKeys("key-a", "key-b"), WithBaseURL(server.URL), WithTaskStore(store))
+	for i := 0; i < 2; i++ {
+		if _, err := client.Submit("wavespeed-ai/z-image/turbo", nil); err != nil {
+			t.Fatalf("submit error: %v", err)
+		}
+	}
+
+	record, _ := store.Load("req-1")
+	if record == nil || record.KeyID == "" || strings.Contains(record.KeyID, "key-a") {
+		t.Fatalf("expected a key fingerprint in the record, got %+v", record)
+	}
+
+	// A restarted client with the keys in another order still polls each
+	// task with the key that submitted it.
+	restarted := NewClient(WithAPIKeys("key-b", "key-a"), WithBaseURL(server.URL), WithTaskStore(store))
+	for _, id := range []string{"req-1", "req-2"} {
+		result := restarted.Attach(context.Background(), id, WithPollInterval(0.01))
+		if result.Outputs == nil {
+			t.Errorf("expected task %s to complete, got %+v", id, result.Detail)
+		}
+	}
+}
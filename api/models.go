@@ -0,0 +1,289 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Model describes a model in the WaveSpeed catalog.
+type Model struct {
+	ID          string `json:"model_id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Category is the kind of model, e.g. "text-to-image" or "image-to-video".
+	Category string  `json:"category"`
+	Pricing  Pricing `json:"pricing"`
+	// SyncMode reports whether the model supports WithSyncMode.
+	SyncMode     bool    `json:"sync_mode"`
+	InputSchema  *Schema `json:"input_schema,omitempty"`
+	OutputSchema *Schema `json:"output_schema,omitempty"`
+}
+
+// Pricing is the price of a model run.
+type Pricing struct {
+	// BasePrice is the price of one Unit.
+	BasePrice float64 `json:"base_price"`
+	Currency  string  `json:"currency"`
+	// Unit is what BasePrice is charged per, e.g. "request", "image" or "second".
+	Unit string `json:"unit"`
+	// ScaleBy names a numeric input field the price is multiplied by, such as
+	// "duration" or "num_images". It is empty for a flat price.
+	ScaleBy string `json:"scale_by,omitempty"`
+}
+
+// Schema is the subset of JSON Schema used to describe model inputs and outputs.
+type Schema struct {
+	Type        string             `json:"type,omitempty"`
+	Title       string             `json:"title,omitempty"`
+	Description string             `json:"description,omitempty"`
+	Properties  map[string]*Schema `json:"properties,omitempty"`
+	Required    []string           `json:"required,omitempty"`
+	Items       *Schema            `json:"items,omitempty"`
+	Enum        []any              `json:"enum,omitempty"`
+	Default     any                `json:"default,omitempty"`
+	Format      string             `json:"format,omitempty"`
+	Minimum     *float64           `json:"minimum,omitempty"`
+	Maximum     *float64           `json:"maximum,omitempty"`
+	MinLength   *int               `json:"minLength,omitempty"`
+	MaxLength   *int               `json:"maxLength,omitempty"`
+	MinItems    *int               `json:"minItems,omitempty"`
+	MaxItems    *int               `json:"maxItems,omitempty"`
+	// AdditionalProperties is false when keys outside Properties are not
+	// allowed. It may also hold a schema for such keys.
+	AdditionalProperties any `json:"additionalProperties,omitempty"`
+}
+
+// WithModelCacheTTL sets how long ListModels and GetModel reuse a fetched
+// catalog (10 minutes by default). A zero TTL disables caching.
+func WithModelCacheTTL(ttl time.Duration) ClientOption {
+	return func(c *Client) {
+		c.catalog.ttl = ttl
+	}
+}
+
+// modelCatalog caches the model list.
+type modelCatalog struct {
+	ttl time.Duration
+
+	mu        sync.Mutex
+	models    []Model
+	fetchedAt time.Time
+	// fetch is the fetch in progress, shared by concurrent callers.
+	fetch *catalogFetch
+}
+
+// catalogFetch is a fetch of the model list; done is closed once models or
+// err is set.
+type catalogFetch struct {
+	done   chan struct{}
+	models []Model
+	err    error
+}
+
+// ListModels returns the models available to the account. The slice is a
+// copy, so callers may modify it.
+//
+// Example:
+//
+//	models, err := client.ListModels(ctx)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	for _, m := range models {
+//	    fmt.Println(m.ID, m.Category, m.Pricing.BasePrice)
+//	}
+func (c *Client) ListModels(ctx context.Context) ([]Model, error) {
+	models, err := c.catalogModels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return append([]Model(nil), models...), nil
+}
+
+// catalogModels returns the cached model list, fetching it when it is stale.
+// Concurrent callers share a single fetch, and the lock is not held while
+// it runs. The returned slice must not be modified.
+func (c *Client) catalogModels(ctx context.Context) ([]Model, error) {
+	c.catalog.mu.Lock()
+	if c.catalog.models != nil && time.Since(c.catalog.fetchedAt) < c.catalog.ttl {
+		models := c.catalog.models
+		c.catalog.mu.Unlock()
+		return models, nil
+	}
+
+	fetch := c.catalog.fetch
+	if fetch != nil {
+		c.catalog.mu.Unlock()
+		select {
+		case <-fetch.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		return fetch.models, fetch.err
+	}
+	fetch = &catalogFetch{done: make(chan struct{})}
+	c.catalog.fetch = fetch
+	c.catalog.mu.Unlock()
+
+	var models []Model
+	err := c.getJSON(ctx, "/api/v3/models", &models)
+	if err != nil {
+		models, err = nil, fmt.Errorf("failed to list models: %w", err)
+	} else if models == nil {
+		models = []Model{}
+	}
+
+	c.catalog.mu.Lock()
+	fetch.models, fetch.err = models, err
+	c.catalog.fetch = nil
+	if err == nil {
+		c.catalog.models = models
+		c.catalog.fetchedAt = time.Now()
+	}
+	c.catalog.mu.Unlock()
+	close(fetch.done)
+	return models, err
+}
+
+// GetModel returns the catalog entry for the model with the given ID, such as
+// "wavespeed-ai/z-image/turbo".
+func (c *Client) GetModel(ctx context.Context, id string) (*Model, error) {
+	models, err := c.catalogModels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range models {
+		if models[i].ID == id {
+			model := models[i]
+			return &model, nil
+		}
+	}
+	return nil, fmt.Errorf("model not found: %s", id)
+}
+
+// apiResponse is the envelope around every API response.
+type apiResponse struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// getJSON sends a GET request for path and decodes the data field of the
+// response into out. Like submissions, connection errors are retried and
+// fail over through the client's endpoints in rotation.
+func (c *Client) getJSON(ctx context.Context, path string, out any) error {
+	apiKey := c.keys.acquire()
+	defer c.keys.release(apiKey)
+
+	// Each endpoint gets maxConnectionRetries+1 attempts, tried in rotation.
+	endpoints := c.endpointOrder()
+	attempts := (c.maxConnectionRetries + 1) * len(endpoints)
+
+	var lastErr error
+	for retry := 0; retry < attempts; retry++ {
+		baseURL := endpoints[retry%len(endpoints)]
+		failover := len(endpoints) > 1 && (retry+1)%len(endpoints) != 0
+
+		status, err := c.getJSONOnce(ctx, baseURL+path, apiKey, out)
+		if err == nil {
+			c.endpoints.success(baseURL)
+			return nil
+		}
+		if status == 0 {
+			// No response: a connection error.
+			lastErr = err
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			c.endpoints.failure(baseURL, err)
+			if failover {
+				c.logf("Connection error on %s: %v\n", baseURL, err)
+				c.logf("Failing over to %s...\n", endpoints[(retry+1)%len(endpoints)])
+				continue
+			}
+			if retry < attempts-1 {
+				round := retry / len(endpoints)
+				delay := c.retryInterval * float64(round+1)
+				c.logf("Connection error on attempt %d/%d:\n", round+1, c.maxConnectionRetries+1)
+				c.logf("%v\n", err)
+				c.logf("Retrying in %.1f seconds...\n", delay)
+				timer := time.NewTimer(time.Duration(delay * float64(time.Second)))
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return ctx.Err()
+				case <-timer.C:
+				}
+				continue
+			}
+			return fmt.Errorf("request failed after %d attempts: %w", attempts, lastErr)
+		}
+		// Server errors fail over once through the other endpoints.
+		if status >= 500 {
+			c.endpoints.failure(baseURL, err)
+			if failover && retry < len(endpoints)-1 {
+				c.logf("%v\n", err)
+				c.logf("Failing over to %s...\n", endpoints[retry+1])
+				continue
+			}
+		}
+		return err
+	}
+
+	return fmt.Errorf("request failed after %d attempts: %w", attempts, lastErr)
+}
+
+// getJSONOnce makes a single attempt of getJSON against url. It returns the
+// HTTP status of the response, 0 if the request got no response, or -1 if it
+// could not be made.
+func (c *Client) getJSONOnce(ctx context.Context, url, apiKey string, out any) (int, error) {
+	timeout := time.Duration(c.connectionTimeout * float64(time.Second))
+	reqCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(reqCtx, "GET", url, nil)
+	if err != nil {
+		return -1, err
+	}
+	headers, err := c.headersWithKey(apiKey)
+	if err != nil {
+		return -1, err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	client := &http.Client{
+		Timeout: timeout,
+	}
+	resp, err := c.do(client, req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusUnauthorized {
+			c.keys.penalize(apiKey)
+		}
+		bodyText, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(bodyText))
+	}
+
+	var result apiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return resp.StatusCode, err
+	}
+	if result.Code != 0 && result.Code != 200 {
+		return resp.StatusCode, fmt.Errorf("API error %d: %s", result.Code, result.Message)
+	}
+	if len(result.Data) == 0 {
+		return resp.StatusCode, nil
+	}
+	return resp.StatusCode, json.Unmarshal(result.Data, out)
+}
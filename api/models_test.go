@@ -0,0 +1,201 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testCatalog = `{"code":200,"message":"success","data":[
+	{
+		"model_id": "wavespeed-ai/z-image/turbo",
+		"name": "Z-Image Turbo",
+		"description": "Fast text-to-image model",
+		"category": "text-to-image",
+		"pricing": {"base_price": 0.005, "currency": "USD", "unit": "image", "scale_by": "num_images"},
+		"sync_mode": true,
+		"input_schema": {
+			"type": "object",
+			"required": ["prompt"],
+			"additionalProperties": false,
+			"properties": {
+				"prompt": {"type": "string", "description": "Text prompt", "minLength": 1},
+				"size": {"type": "string", "enum": ["512*512", "1024*1024"], "default": "1024*1024"},
+				"seed": {"type": "integer", "minimum": -1, "maximum": 2147483647, "default": -1},
+				"num_images": {"type": "integer", "minimum": 1, "maximum": 4, "default": 1},
+				"guidance_scale": {"type": "number", "minimum": 0, "maximum": 20},
+				"enable_safety_checker": {"type": "boolean", "default": true},
+				"loras": {"type": "array", "maxItems": 2, "items": {
+					"type": "object",
+					"required": ["path"],
+					"properties": {"path": {"type": "string"}, "scale": {"type": "number", "minimum": 0, "maximum": 4}}
+				}}
+			}
+		},
+		"output_schema": {"type": "array", "items": {"type": "string", "format": "uri"}}
+	},
+	{
+		"model_id": "wavespeed-ai/wan-2.1/t2v-480p",
+		"name": "Wan 2.1 T2V 480p",
+		"category": "text-to-video",
+		"pricing": {"base_price": 0.05, "currency": "USD", "unit": "second", "scale_by": "duration"},
+		"sync_mode": false,
+		"input_schema": {"type": "object", "required": ["prompt"], "properties": {
+			"prompt": {"type": "string"},
+			"duration": {"type": "integer", "enum": [5, 10], "default": 5}
+		}}
+	}
+]}`
+
+func newCatalogServer(t *testing.T, hits *int) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v3/models" {
+			http.NotFound(w, r)
+			return
+		}
+		if hits != nil {
+			*hits++
+		}
+		w.Write([]byte(testCatalog))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestListModels(t *testing.T) {
+	hits := 0
+	server := newCatalogServer(t, &hits)
+	client := NewClient(WithAPIKey("test-key"), WithBaseURL(server.URL))
+
+	models, err := client.ListModels(context.Background())
+	if err != nil {
+		t.Fatalf("list error: %v", err)
+	}
+	if len(models) != 2 {
+		t.Fatalf("expected 2 models, got %d", len(models))
+	}
+	m := models[0]
+	if m.ID != "wavespeed-ai/z-image/turbo" || m.Category != "text-to-image" || !m.SyncMode {
+		t.Errorf("unexpected model: %+v", m)
+	}
+	if m.Pricing.BasePrice != 0.005 || m.Pricing.Unit != "image" || m.Pricing.ScaleBy != "num_images" {
+		t.Errorf("unexpected pricing: %+v", m.Pricing)
+	}
+	if m.InputSchema == nil || m.InputSchema.Properties["seed"].Type != "integer" || *m.InputSchema.Properties["seed"].Maximum != 2147483647 {
+		t.Errorf("unexpected input schema: %+v", m.InputSchema)
+	}
+	if m.OutputSchema == nil || m.OutputSchema.Items.Format != "uri" {
+		t.Errorf("unexpected output schema: %+v", m.OutputSchema)
+	}
+
+	model, err := client.GetModel(context.Background(), "wavespeed-ai/wan-2.1/t2v-480p")
+	if err != nil || model.Pricing.ScaleBy != "duration" {
+		t.Errorf("unexpected model: %+v, %v", model, err)
+	}
+	if hits != 1 {
+		t.Errorf("expected the catalog to be cached, got %d requests", hits)
+	}
+
+	if _, err := client.GetModel(context.Background(), "acme/missing"); err == nil || !strings.Contains(err.Error(), "model not found") {
+		t.Errorf("expected model not found error, got %v", err)
+	}
+}
+
+func TestListModelsCacheTTL(t *testing.T) {
+	hits := 0
+	server := newCatalogServer(t, &hits)
+	client := NewClient(WithAPIKey("test-key"), WithBaseURL(server.URL), WithModelCacheTTL(0))
+
+	client.ListModels(context.Background())
+	client.ListModels(context.Background())
+	if hits != 2 {
+		t.Errorf("expected caching to be disabled, got %d requests", hits)
+	}
+}
+
+func TestListModelsHTTPError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte("invalid api key"))
+	}))
+	defer server.Close()
+
+	client := NewClient(WithAPIKey("test-key"), WithBaseURL(server.URL))
+	_, err := client.ListModels(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "HTTP 401") {
+		t.Errorf("expected HTTP 401 error, got %v", err)
+	}
+}
+
+func TestListModelsSharedFetchAndCopy(t *testing.T) {
+	var hits int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		<-release
+		w.Write([]byte(testCatalog))
+	}))
+	defer server.Close()
+	client := NewClient(WithAPIKey("test-key"), WithBaseURL(server.URL))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if models, err := client.ListModels(context.Background()); err != nil || len(models) != 2 {
+				t.Errorf("unexpected list result: %d models, %v", len(models), err)
+			}
+		}()
+	}
+	for atomic.LoadInt32(&hits) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	// The lock is not held while fetching, so a canceled caller returns.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := client.ListModels(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled while the fetch is running, got %v", err)
+	}
+	close(release)
+	wg.Wait()
+	if n := atomic.LoadInt32(&hits); n != 1 {
+		t.Errorf("expected concurrent calls to share one fetch, got %d requests", n)
+	}
+
+	models, _ := client.ListModels(context.Background())
+	models[0].ID = "changed"
+	models, _ = client.ListModels(context.Background())
+	if models[0].ID != "wavespeed-ai/z-image/turbo" {
+		t.Errorf("expected the cached catalog to be unaffected, got %s", models[0].ID)
+	}
+}
+
+func TestListModelsFailsOverAndHonorsContext(t *testing.T) {
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+	server := newCatalogServer(t, nil)
+
+	client := NewClient(WithAPIKey("test-key"), WithEndpoints(down.URL, server.URL), WithLogOutput(io.Discard))
+	if models, err := client.ListModels(context.Background()); err != nil || len(models) != 2 {
+		t.Fatalf("expected failover to the second endpoint, got %d models, %v", len(models), err)
+	}
+
+	client = NewClient(WithAPIKey("test-key"), WithBaseURL(down.URL), WithRetryInterval(10), WithLogOutput(io.Discard))
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := client.ListModels(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected the retry delay to stop at the deadline, took %v", elapsed)
+	}
+}
@@ -0,0 +1,166 @@
+package api
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PollState describes a task being polled, for a PollStrategy to decide when
+// to check it again.
+type PollState struct {
+	// Model is the model the task runs on. It is empty for Wait, which only
+	// knows the task ID.
+	Model string
+	// Attempt counts the status checks made so far, starting at 0 after the first.
+	Attempt int
+	// Elapsed is the time since polling started.
+	Elapsed time.Duration
+	// Hint is the delay the server asked for with a Retry-After header, or 0.
+	Hint time.Duration
+}
+
+// PollStrategy decides how long to wait between status checks.
+//
+// The client never polls sooner than the server's hint and never sleeps past
+// the task deadline, whatever the strategy returns.
+type PollStrategy interface {
+	NextPoll(state PollState) time.Duration
+}
+
+// PollStrategyFunc adapts a function to a PollStrategy.
+type PollStrategyFunc func(state PollState) time.Duration
+
+// NextPoll calls f(state).
+func (f PollStrategyFunc) NextPoll(state PollState) time.Duration {
+	return f(state)
+}
+
+// FixedPoll checks the task every interval. It is what WithPollInterval uses.
+func FixedPoll(interval time.Duration) PollStrategy {
+	return PollStrategyFunc(func(PollState) time.Duration {
+		return interval
+	})
+}
+
+// minExponentialPoll is the shortest first delay of ExponentialPoll.
+const minExponentialPoll = 100 * time.Millisecond
+
+// ExponentialPoll starts at initial and multiplies the delay by factor after
+// each check, up to max. A factor below 1 is treated as 2, an initial delay
+// below 100ms as 100ms, and a max below initial as initial.
+//
+// Example:
+//
+//	// 250ms, 500ms, 1s, 2s, 4s, 5s, 5s, ...
+//	strategy := api.ExponentialPoll(250*time.Millisecond, 5*time.Second, 2)
+func ExponentialPoll(initial, max time.Duration, factor float64) PollStrategy {
+	if factor < 1 {
+		factor = 2
+	}
+	if initial < minExponentialPoll {
+		initial = minExponentialPoll
+	}
+	if max < initial {
+		max = initial
+	}
+	return PollStrategyFunc(func(state PollState) time.Duration {
+		delay := float64(initial) * math.Pow(factor, float64(state.Attempt))
+		if delay > float64(max) {
+			return max
+		}
+		return time.Duration(delay)
+	})
+}
+
+// ModelAwarePoll picks a strategy by model, so fast image models are checked
+// often while slow video models are not. Keys match a model exactly or as a
+// prefix, such as "wavespeed-ai/wan-2.1"; the longest match wins. Models
+// without a match, and tasks whose model is unknown, use fallback, which
+// defaults to an exponential backoff from 250ms up to 5 seconds.
+//
+// Example:
+//
+//	strategy := api.ModelAwarePoll(map[string]api.PollStrategy{
+//	    "wavespeed-ai/z-image": api.FixedPoll(200 * time.Millisecond),
+//	    "wavespeed-ai/wan-2.1": api.ExponentialPoll(2*time.Second, 15*time.Second, 1.5),
+//	}, nil)
+func ModelAwarePoll(models map[string]PollStrategy, fallback PollStrategy) PollStrategy {
+	if fallback == nil {
+		fallback = ExponentialPoll(250*time.Millisecond, 5*time.Second, 1.5)
+	}
+	return PollStrategyFunc(func(state PollState) time.Duration {
+		strategy, matched := fallback, ""
+		for prefix, s := range models {
+			if strings.HasPrefix(state.Model, prefix) && len(prefix) > len(matched) {
+				strategy, matched = s, prefix
+			}
+		}
+		return strategy.NextPoll(state)
+	})
+}
+
+// WithPollStrategy sets how long to wait between status checks. It replaces
+// the fixed interval set with WithPollInterval.
+//
+// Example:
+//
+//	output, err := client.Run(model, input,
+//	    api.WithPollStrategy(api.ExponentialPoll(200*time.Millisecond, 10*time.Second, 2)),
+//	)
+func WithPollStrategy(strategy PollStrategy) RunOption {
+	return func(o *RunOptions) {
+		o.PollStrategy = strategy
+	}
+}
+
+// WithPollTimeout sets the HTTP timeout in seconds for each status check,
+// independently of the task timeout. It defaults to the client's connection
+// timeout. A status check never runs past the task deadline.
+func WithPollTimeout(timeout float64) RunOption {
+	return func(o *RunOptions) {
+		o.PollTimeout = timeout
+	}
+}
+
+func (o *RunOptions) pollStrategy() PollStrategy {
+	if o.PollStrategy != nil {
+		return o.PollStrategy
+	}
+	return FixedPoll(time.Duration(o.PollInterval * float64(time.Second)))
+}
+
+// pollTimeout returns the HTTP timeout for a single status check.
+func (c *Client) pollTimeout(options *RunOptions) time.Duration {
+	timeout := options.PollTimeout
+	if timeout <= 0 {
+		timeout = c.connectionTimeout
+	}
+	return time.Duration(timeout * float64(time.Second))
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP date.
+func retryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		if seconds > 0 {
+			return time.Duration(seconds * float64(time.Second))
+		}
+		return 0
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// isRateLimitError reports whether err is an HTTP 429 response.
+func isRateLimitError(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "HTTP 429")
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestPollStrategies(t *testing.T) {
+	exp := ExponentialPoll(100*time.Millisecond, time.Second, 2)
+	want := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second}
+	for attempt, w := range want {
+		if got := exp.NextPoll(PollState{Attempt: attempt}); got != w {
+			t.Errorf("exponential attempt %d: got %v, want %v", attempt, got, w)
+		}
+	}
+
+	clamped := ExponentialPoll(0, 0, 0)
+	for attempt := 0; attempt < 3; attempt++ {
+		if got := clamped.NextPoll(PollState{Attempt: attempt}); got != 100*time.Millisecond {
+			t.Errorf("clamped attempt %d: got %v, want 100ms", attempt, got)
+		}
+	}
+	if got := ExponentialPoll(-time.Second, time.Second, 2).NextPoll(PollState{Attempt: 1}); got != 200*time.Millisecond {
+		t.Errorf("negative initial: got %v, want 200ms", got)
+	}
+
+	if got := FixedPoll(time.Second).NextPoll(PollState{Attempt: 10}); got != time.Second {
+		t.Errorf("fixed: got %v", got)
+	}
+
+	aware := ModelAwarePoll(map[string]PollStrategy{
+		"wavespeed-ai/":          FixedPoll(time.Second),
+		"wavespeed-ai/wan-2.1/":  FixedPoll(10 * time.Second),
+		"wavespeed-ai/z-image/t": FixedPoll(100 * time.Millisecond),
+	}, nil)
+	cases := map[string]time.Duration{
+		"wavespeed-ai/wan-2.1/t2v-480p": 10 * time.Second,
+		"wavespeed-ai/z-image/turbo":    100 * time.Millisecond,
+		"wavespeed-ai/flux-dev":         time.Second,
+		"":                              250 * time.Millisecond,
+	}
+	for model, w := range cases {
+		if got := aware.NextPoll(PollState{Model: model}); got != w {
+			t.Errorf("model-aware %q: got %v, want %v", model, got, w)
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	if got := retryAfter("2"); got != 2*time.Second {
+		t.Errorf("seconds: got %v", got)
+	}
+	if got := retryAfter(time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)); got < 58*time.Second || got > time.Minute {
+		t.Errorf("date: got %v", got)
+	}
+	if got := retryAfter("soon"); got != 0 {
+		t.Errorf("invalid: got %v", got)
+	}
+}
+
+func TestWaitUsesStrategyAndServerHints(t *testing.T) {
+	var polls []time.Time
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/predictions/req-123/result", func(w http.ResponseWriter, r *http.Request) {
+		polls = append(polls, time.Now())
+		switch len(polls) {
+		case 1:
+			w.Header().Set("Retry-After", "0.2")
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte("slow down"))
+		case 2:
+			w.Write([]byte(`{"code":200,"data":{"status":"processing"}}`))
+		default:
+			w.Write([]byte(`{"code":200,"data":{"status":"completed","outputs":["https://example.com/out.png"]}}`))
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	var states []PollState
+	strategy := PollStrategyFunc(func(state PollState) time.Duration {
+		states = append(states, state)
+		return 10 * time.Millisecond
+	})
+
+	client := NewClient(WithAPIKey("test-key"), WithBaseURL(server.URL))
+	result, err := client.Wait("req-123", WithPollStrategy(strategy), WithTimeout(5))
+	if err != nil {
+		t.Fatalf("wait error: %v", err)
+	}
+	if outputs := result["outputs"].([]any); len(outputs) != 1 {
+		t.Errorf("unexpected outputs: %+v", result)
+	}
+
+	if len(polls) != 3 || len(states) != 2 {
+		t.Fatalf("expected 3 polls and 2 strategy calls, got %d and %d", len(polls), len(states))
+	}
+	if gap := polls[1].Sub(polls[0]); gap < 200*time.Millisecond {
+		t.Errorf("expected Retry-After to be honored, polled again after %v", gap)
+	}
+	if states[0].Hint != 200*time.Millisecond || states[1].Attempt != 1 {
+		t.Errorf("unexpected poll states: %+v", states)
+	}
+}
+
+func TestWaitBoundsPollRequestsByDeadline(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := NewClient(WithAPIKey("test-key"), WithBaseURL(server.URL), WithMaxConnectionRetries(0))
+	start := time.Now()
+	_, err := client.Wait("req-123", WithTimeout(0.2), WithPollTimeout(30))
+	if err == nil {
+		t.Fatal("expected timeout error")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("expected the poll to stop at the task deadline, took %v", elapsed)
+	}
+}
@@ -0,0 +1,473 @@
+package api
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/WaveSpeedAI/wavespeed-go/internal/yamlsubset"
+)
+
+// Profile is a named set of client settings loaded from a config file.
+//
+// Unset fields leave the corresponding client setting untouched.
+type Profile struct {
+	APIKey               string
+	BaseURL              string
+	ConnectionTimeout    *float64
+	MaxRetries           *int
+	MaxConnectionRetries *int
+	RetryInterval        *float64
+}
+
+// Config is the content of a WaveSpeed config file.
+type Config struct {
+	// DefaultProfile is used when no profile name is given.
+	DefaultProfile string
+	// Profiles maps profile names to their settings. The empty name holds
+	// the top-level settings shared by all profiles.
+	Profiles map[string]Profile
+}
+
+// Environment variables that override config file settings.
+const (
+	EnvAPIKey               = "WAVESPEED_API_KEY"
+	EnvBaseURL              = "WAVESPEED_BASE_URL"
+	EnvConnectionTimeout    = "WAVESPEED_CONNECTION_TIMEOUT"
+	EnvMaxRetries           = "WAVESPEED_MAX_RETRIES"
+	EnvMaxConnectionRetries = "WAVESPEED_MAX_CONNECTION_RETRIES"
+	EnvRetryInterval        = "WAVESPEED_RETRY_INTERVAL"
+	EnvProfile              = "WAVESPEED_PROFILE"
+	EnvConfig               = "WAVESPEED_CONFIG"
+)
+
+// DefaultConfigPath returns the config file used by WithProfile.
+//
+// It is $WAVESPEED_CONFIG if set, otherwise the first existing file among
+// config.toml, config.yaml and config.yml in $XDG_CONFIG_HOME/wavespeed
+// (~/.config/wavespeed by default). An empty string means no file was found.
+func DefaultConfigPath() string {
+	if path := os.Getenv(EnvConfig); path != "" {
+		return path
+	}
+
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+
+	for _, name := range []string{"config.toml", "config.yaml", "config.yml"} {
+		path := filepath.Join(dir, "wavespeed", name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// LoadConfig reads a TOML or YAML config file, chosen by its extension.
+//
+// Example config.toml:
+//
+//	default_profile = "prod"
+//
+//	[profiles.prod]
+//	api_key = "prod-key"
+//
+//	[profiles.staging]
+//	api_key = "staging-key"
+//	base_url = "https://staging.api.wavespeed.ai"
+//	max_retries = 2
+//
+// The same file in YAML:
+//
+//	default_profile: prod
+//	profiles:
+//	  prod:
+//	    api_key: prod-key
+//	  staging:
+//	    api_key: staging-key
+//	    base_url: https://staging.api.wavespeed.ai
+//	    max_retries: 2
+//
+// Settings at the top level apply to every profile unless overridden.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var values map[string]string
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		values, err = parseYAMLConfig(string(data))
+	default:
+		values, err = parseTOMLConfig(string(data))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+
+	config, err := buildConfig(values)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return config, nil
+}
+
+// Profile returns the named profile, or the default profile when name is
+// empty. A missing default profile yields an empty Profile.
+func (c *Config) Profile(name string) (Profile, error) {
+	if name == "" {
+		name = c.DefaultProfile
+		if name == "" {
+			name = "default"
+		}
+		if _, ok := c.Profiles[name]; !ok {
+			return c.Profiles[""], nil
+		}
+	}
+
+	profile, ok := c.Profiles[name]
+	if !ok {
+		names := make([]string, 0, len(c.Profiles))
+		for n := range c.Profiles {
+			if n != "" {
+				names = append(names, n)
+			}
+		}
+		sort.Strings(names)
+		return Profile{}, fmt.Errorf("profile %q not found (available: %s)", name, strings.Join(names, ", "))
+	}
+	return profile, nil
+}
+
+// WithProfile configures the client from a named profile in the default
+// config file (see DefaultConfigPath), then applies the WAVESPEED_*
+// environment variables on top of it.
+//
+// An empty name selects $WAVESPEED_PROFILE, then the file's default_profile.
+// Errors (unreadable file, unknown profile) are reported by the first request.
+// Options are applied in order, so put WithProfile before options that should
+// take precedence over it.
+//
+// Example:
+//
+//	client := api.NewClient(api.WithProfile("staging"))
+func WithProfile(name string) ClientOption {
+	return func(c *Client) {
+		if name == "" {
+			name = os.Getenv(EnvProfile)
+		}
+
+		path := DefaultConfigPath()
+		if path == "" {
+			if name != "" {
+				c.configErr = fmt.Errorf("profile %q not found: no config file", name)
+			}
+			return
+		}
+
+		config, err := LoadConfig(path)
+		if err != nil {
+			c.configErr = err
+			return
+		}
+		profile, err := config.Profile(name)
+		if err != nil {
+			c.configErr = err
+			return
+		}
+
+		profile.apply(c)
+		applyEnv(c)
+	}
+}
+
+func (p Profile) apply(c *Client) {
+	if p.APIKey != "" {
+		c.apiKey = p.APIKey
+	}
+	if p.BaseURL != "" {
+		c.baseURL = p.BaseURL
+		c.endpoints = nil
+	}
+	if p.ConnectionTimeout != nil {
+		c.connectionTimeout = *p.ConnectionTimeout
+	}
+	if p.MaxRetries != nil {
+		c.maxRetries = *p.MaxRetries
+	}
+	if p.MaxConnectionRetries != nil {
+		c.maxConnectionRetries = *p.MaxConnectionRetries
+	}
+	if p.RetryInterval != nil {
+		c.retryInterval = *p.RetryInterval
+	}
+}
+
+// applyEnv applies the WAVESPEED_* environment overrides to c. Like the
+// package-level wavespeed.API, it ignores values that do not parse.
+func applyEnv(c *Client) {
+	for key, env := range map[string]string{
+		"api_key":                EnvAPIKey,
+		"base_url":               EnvBaseURL,
+		"connection_timeout":     EnvConnectionTimeout,
+		"max_retries":            EnvMaxRetries,
+		"max_connection_retries": EnvMaxConnectionRetries,
+		"retry_interval":         EnvRetryInterval,
+	} {
+		v := os.Getenv(env)
+		if v == "" {
+			continue
+		}
+		if profile, err := buildProfile(map[string]string{key: v}); err == nil {
+			profile.apply(c)
+		}
+	}
+}
+
+// buildConfig turns flattened "profiles.<name>.<key>" values into a Config.
+// Profile "" holds the top-level settings every profile inherits.
+func buildConfig(values map[string]string) (*Config, error) {
+	config := &Config{Profiles: map[string]Profile{}}
+	base := map[string]string{}
+	profiles := map[string]map[string]string{}
+
+	for key, value := range values {
+		switch {
+		case key == "default_profile":
+			config.DefaultProfile = value
+		case strings.HasPrefix(key, "profiles."):
+			rest := strings.TrimPrefix(key, "profiles.")
+			i := strings.LastIndex(rest, ".")
+			if i <= 0 {
+				return nil, fmt.Errorf("unexpected key %q", key)
+			}
+			name := rest[:i]
+			if profiles[name] == nil {
+				profiles[name] = map[string]string{}
+			}
+			profiles[name][rest[i+1:]] = value
+		default:
+			base[key] = value
+		}
+	}
+
+	profile, err := buildProfile(base)
+	if err != nil {
+		return nil, err
+	}
+	config.Profiles[""] = profile
+
+	for name, settings := range profiles {
+		merged := map[string]string{}
+		for k, v := range base {
+			merged[k] = v
+		}
+		for k, v := range settings {
+			merged[k] = v
+		}
+		profile, err := buildProfile(merged)
+		if err != nil {
+			return nil, fmt.Errorf("profile %q: %w", name, err)
+		}
+		config.Profiles[name] = profile
+	}
+	return config, nil
+}
+
+func buildProfile(values map[string]string) (Profile, error) {
+	var p Profile
+	for key, value := range values {
+		var err error
+		switch key {
+		case "api_key":
+			p.APIKey = value
+		case "base_url":
+			p.BaseURL = value
+		case "connection_timeout":
+			p.ConnectionTimeout, err = parseFloatSetting(value)
+		case "max_retries":
+			p.MaxRetries, err = parseIntSetting(value)
+		case "max_connection_retries":
+			p.MaxConnectionRetries, err = parseIntSetting(value)
+		case "retry_interval":
+			p.RetryInterval, err = parseFloatSetting(value)
+		default:
+			return p, fmt.Errorf("unknown setting %q", key)
+		}
+		if err != nil {
+			return p, fmt.Errorf("%s: %w", key, err)
+		}
+	}
+	return p, nil
+}
+
+func parseFloatSetting(value string) (*float64, error) {
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+func parseIntSetting(value string) (*int, error) {
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, err
+	}
+	return &i, nil
+}
+
+// parseTOMLConfig parses the subset of TOML used by config files: tables,
+// dotted table names and scalar key/value pairs. Keys are flattened with dots.
+func parseTOMLConfig(data string) (map[string]string, error) {
+	values := map[string]string{}
+	prefix := ""
+
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(stripComment(scanner.Text()))
+		if text == "" {
+			continue
+		}
+
+		if strings.HasPrefix(text, "[") {
+			if !strings.HasSuffix(text, "]") || strings.HasPrefix(text, "[[") {
+				return nil, fmt.Errorf("line %d: invalid table header", line)
+			}
+			name, err := joinKey(strings.Trim(text, "[]"))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			prefix = name + "."
+			continue
+		}
+
+		key, value, ok := strings.Cut(text, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key = value", line)
+		}
+		name, err := joinKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		v, err := parseScalar(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		values[prefix+name] = v
+	}
+	return values, scanner.Err()
+}
+
+// parseYAMLConfig parses the subset of YAML used by config files: nested
+// mappings of scalars. Keys are flattened with dots.
+func parseYAMLConfig(data string) (map[string]string, error) {
+	doc, err := yamlsubset.ParseStrings(data)
+	if err != nil {
+		return nil, err
+	}
+	values := map[string]string{}
+	if doc == nil {
+		return values, nil
+	}
+	if err := flattenYAML(values, "", doc); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// flattenYAML adds the scalars in value to values under dotted keys.
+func flattenYAML(values map[string]string, key string, value any) error {
+	switch v := value.(type) {
+	case map[string]any:
+		for k, child := range v {
+			if key != "" {
+				k = key + "." + k
+			}
+			if err := flattenYAML(values, k, child); err != nil {
+				return err
+			}
+		}
+	case []any:
+		if key == "" {
+			return errors.New("expected a mapping")
+		}
+		return fmt.Errorf("%s: lists are not supported", key)
+	case string:
+		if key == "" {
+			return errors.New("expected a mapping")
+		}
+		values[key] = v
+	}
+	return nil
+}
+
+// joinKey normalizes a possibly quoted, dotted key such as profiles."prod".
+func joinKey(key string) (string, error) {
+	parts := strings.Split(strings.TrimSpace(key), ".")
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		if unquoted, err := parseScalar(part); err == nil {
+			part = unquoted
+		}
+		if part == "" {
+			return "", fmt.Errorf("invalid key %q", key)
+		}
+		parts[i] = part
+	}
+	return strings.Join(parts, "."), nil
+}
+
+func parseScalar(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, `"`):
+		s, err := strconv.Unquote(value)
+		if err != nil {
+			return "", fmt.Errorf("invalid string %s", value)
+		}
+		return s, nil
+	case strings.HasPrefix(value, "'"):
+		if len(value) < 2 || !strings.HasSuffix(value, "'") {
+			return "", fmt.Errorf("invalid string %s", value)
+		}
+		return value[1 : len(value)-1], nil
+	case strings.HasPrefix(value, "[") || strings.HasPrefix(value, "{"):
+		return "", errors.New("arrays and inline tables are not supported")
+	}
+	return value, nil
+}
+
+// stripComment removes a trailing # comment that is not inside quotes.
+func stripComment(line string) string {
+	var quote rune
+	escaped := false
+	for i, r := range line {
+		switch {
+		case escaped:
+			escaped = false
+		case quote == '"' && r == '\\':
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
@@ -0,0 +1,167 @@
+package api
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testTOMLConfig = `# WaveSpeed profiles
+default_profile = "prod"
+connection_timeout = 5
+
+[profiles.prod]
+api_key = "prod-key" # inline comment
+
+[profiles.staging]
+api_key = 'staging-key'
+base_url = "https://staging.api.wavespeed.ai/#fragment"
+max_retries = 2
+`
+
+const testYAMLConfig = `default_profile: prod
+connection_timeout: 5
+profiles:
+  prod:
+    api_key: "prod-key" # inline comment
+  staging:
+    api_key: staging-key
+    base_url: "https://staging.api.wavespeed.ai/#fragment"
+    max_retries: 2
+`
+
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	for _, tt := range []struct{ name, content string }{
+		{"config.toml", testTOMLConfig},
+		{"config.yaml", testYAMLConfig},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := LoadConfig(writeConfig(t, tt.name, tt.content))
+			if err != nil {
+				t.Fatalf("LoadConfig error: %v", err)
+			}
+			if config.DefaultProfile != "prod" {
+				t.Errorf("expected default profile prod, got %q", config.DefaultProfile)
+			}
+
+			prod, err := config.Profile("")
+			if err != nil {
+				t.Fatalf("Profile error: %v", err)
+			}
+			if prod.APIKey != "prod-key" || prod.ConnectionTimeout == nil || *prod.ConnectionTimeout != 5 {
+				t.Errorf("unexpected prod profile: %+v", prod)
+			}
+
+			staging, err := config.Profile("staging")
+			if err != nil {
+				t.Fatalf("Profile error: %v", err)
+			}
+			if staging.APIKey != "staging-key" || staging.BaseURL != "https://staging.api.wavespeed.ai/#fragment" {
+				t.Errorf("unexpected staging profile: %+v", staging)
+			}
+			if staging.MaxRetries == nil || *staging.MaxRetries != 2 {
+				t.Errorf("expected max_retries=2, got %v", staging.MaxRetries)
+			}
+
+			if _, err := config.Profile("missing"); err == nil || !strings.Contains(err.Error(), "prod, staging") {
+				t.Errorf("expected error listing profiles, got %v", err)
+			}
+		})
+	}
+}
+
+func TestLoadConfigRejectsUnknownSetting(t *testing.T) {
+	_, err := LoadConfig(writeConfig(t, "config.toml", "[profiles.prod]\napi_kye = \"x\"\n"))
+	if err == nil || !strings.Contains(err.Error(), "api_kye") {
+		t.Errorf("expected unknown setting error, got %v", err)
+	}
+}
+
+func TestLoadYAMLConfigValues(t *testing.T) {
+	config, err := LoadConfig(writeConfig(t, "config.yaml", "profiles:\n  prod:\n    api_key: 0123\n"))
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if prod, _ := config.Profile("prod"); prod.APIKey != "0123" {
+		t.Errorf("expected the API key as written, got %q", prod.APIKey)
+	}
+
+	_, err = LoadConfig(writeConfig(t, "config.yaml", "profiles:\n  prod:\n    api_key: [a, b]\n"))
+	if err == nil || !strings.Contains(err.Error(), "profiles.prod.api_key: lists are not supported") {
+		t.Errorf("expected list error, got %v", err)
+	}
+}
+
+func TestWithProfileAndEnvOverrides(t *testing.T) {
+	t.Setenv(EnvConfig, writeConfig(t, "config.toml", testTOMLConfig))
+	t.Setenv(EnvAPIKey, "")
+	t.Setenv(EnvMaxRetries, "4")
+
+	client := NewClient(WithProfile("staging"))
+	if client.apiKey != "staging-key" {
+		t.Errorf("expected profile API key, got %s", client.apiKey)
+	}
+	if client.baseURL != "https://staging.api.wavespeed.ai/#fragment" {
+		t.Errorf("expected profile base URL, got %s", client.baseURL)
+	}
+	if client.maxRetries != 4 {
+		t.Errorf("expected environment to override max_retries, got %d", client.maxRetries)
+	}
+	if client.connectionTimeout != 5 {
+		t.Errorf("expected inherited connection_timeout, got %f", client.connectionTimeout)
+	}
+
+	client = NewClient(WithProfile("staging"), WithAPIKey("explicit-key"))
+	if client.apiKey != "explicit-key" {
+		t.Errorf("expected later option to win, got %s", client.apiKey)
+	}
+
+	// Like WithBaseURL, a profile base URL replaces earlier endpoints.
+	client = NewClient(WithEndpoints("https://a.example.com", "https://b.example.com"), WithProfile("staging"))
+	if order := client.endpointOrder(); len(order) != 1 || order[0] != "https://staging.api.wavespeed.ai/#fragment" {
+		t.Errorf("expected the profile base URL to replace the endpoints, got %v", order)
+	}
+}
+
+func TestWithProfileErrorReportedOnRequest(t *testing.T) {
+	t.Setenv(EnvConfig, writeConfig(t, "config.toml", testTOMLConfig))
+
+	client := NewClient(WithProfile("missing"))
+	_, err := client.getHeaders()
+	if err == nil || !strings.Contains(err.Error(), `profile "missing" not found`) {
+		t.Errorf("expected missing profile error, got %v", err)
+	}
+}
+
+func TestNewClientEnvOverrides(t *testing.T) {
+	t.Setenv(EnvBaseURL, "https://eu.api.wavespeed.ai/")
+	t.Setenv(EnvMaxConnectionRetries, "1")
+
+	client := NewClient(WithAPIKey("test-key"))
+	if client.baseURL != "https://eu.api.wavespeed.ai" {
+		t.Errorf("expected base URL from environment, got %s", client.baseURL)
+	}
+	if client.maxConnectionRetries != 1 {
+		t.Errorf("expected max connection retries from environment, got %d", client.maxConnectionRetries)
+	}
+
+	// Invalid values are ignored, as in wavespeed.API.
+	t.Setenv(EnvRetryInterval, "soon")
+	client = NewClient(WithAPIKey("test-key"))
+	if _, err := client.getHeaders(); err != nil {
+		t.Errorf("expected invalid environment value to be ignored, got %v", err)
+	}
+	if client.retryInterval != 1.0 || client.maxConnectionRetries != 1 {
+		t.Errorf("expected default retry interval and other overrides, got %v and %d", client.retryInterval, client.maxConnectionRetries)
+	}
+}
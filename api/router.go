@@ -0,0 +1,252 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Variant is one arm of a Router experiment.
+type Variant struct {
+	// Name identifies the variant in results and stats. It defaults to Model.
+	Name  string
+	Model string
+	// Weight is the share of traffic the variant receives, relative to the
+	// other variants. When every weight is zero, traffic is split evenly.
+	Weight float64
+	// Options are applied to every run of the variant, after the options
+	// passed to Run, e.g. WithInputAdapter for a model with other parameters.
+	Options []RunOption
+}
+
+// VariantStats summarizes the runs of a variant.
+type VariantStats struct {
+	Variant   string `json:"variant"`
+	Model     string `json:"model"`
+	Requests  int    `json:"requests"`
+	Failures  int    `json:"failures"`
+	LastError string `json:"last_error,omitempty"`
+	// MeanLatency and MaxLatency cover completed runs only.
+	MeanLatency time.Duration `json:"mean_latency"`
+	MaxLatency  time.Duration `json:"max_latency"`
+
+	totalLatency time.Duration
+}
+
+// FailureRate returns the share of requests that failed.
+func (s VariantStats) FailureRate() float64 {
+	if s.Requests == 0 {
+		return 0
+	}
+	return float64(s.Failures) / float64(s.Requests)
+}
+
+// RouterOption configures a Router.
+type RouterOption func(*Router)
+
+// WithExperimentName salts the hash used for routing keys, so that the same
+// key is assigned independently in different experiments.
+func WithExperimentName(name string) RouterOption {
+	return func(r *Router) {
+		r.experiment = name
+	}
+}
+
+// WithRoutingKey assigns the run to a variant by a hash of key, such as a
+// user ID, instead of at random, so the same key always gets the same
+// variant while the weights stay the same. It is only used by Router.
+func WithRoutingKey(key string) RunOption {
+	return func(o *RunOptions) {
+		o.RoutingKey = key
+	}
+}
+
+// Router splits traffic between models for A/B tests. Each run goes to a
+// variant chosen by weight, at random or by routing key, and the variant is
+// recorded in Prediction.Variant and RunDetail.Variant. Per-variant latency
+// and failure counts are available from Stats. A Router is safe for
+// concurrent use.
+//
+// Example:
+//
+//	router, err := api.NewRouter(client, []api.Variant{
+//	    {Name: "control", Model: "wavespeed-ai/flux-dev", Weight: 90},
+//	    {Name: "candidate", Model: "wavespeed-ai/z-image/turbo", Weight: 10},
+//	}, api.WithExperimentName("image-model-q3"))
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//
+//	pred, err := router.Run(ctx, map[string]any{"prompt": "Cat"}, api.WithRoutingKey(userID))
+//	fmt.Println(pred.Variant, pred.Outputs)
+//
+//	for _, s := range router.Stats() {
+//	    fmt.Println(s.Variant, s.Requests, s.FailureRate(), s.MeanLatency)
+//	}
+type Router struct {
+	client     *Client
+	variants   []Variant
+	cumulative []float64
+	experiment string
+
+	mu    sync.Mutex
+	rand  *rand.Rand
+	stats []VariantStats
+}
+
+// NewRouter creates a router that runs variants with client.
+func NewRouter(client *Client, variants []Variant, opts ...RouterOption) (*Router, error) {
+	if len(variants) == 0 {
+		return nil, errors.New("router needs at least one variant")
+	}
+
+	r := &Router{
+		client:   client,
+		variants: make([]Variant, len(variants)),
+		rand:     rand.New(rand.NewSource(time.Now().UnixNano())),
+		stats:    make([]VariantStats, len(variants)),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	total := 0.0
+	names := make(map[string]bool, len(variants))
+	for i, v := range variants {
+		if v.Model == "" {
+			return nil, fmt.Errorf("variant %d has no model", i+1)
+		}
+		if v.Weight < 0 {
+			return nil, fmt.Errorf("variant %d has a negative weight", i+1)
+		}
+		if v.Name == "" {
+			v.Name = v.Model
+		}
+		if names[v.Name] {
+			return nil, fmt.Errorf("duplicate variant name: %s", v.Name)
+		}
+		names[v.Name] = true
+		r.variants[i] = v
+		r.stats[i] = VariantStats{Variant: v.Name, Model: v.Model}
+		total += v.Weight
+	}
+
+	// cumulative holds the upper bound of each variant's share of [0, 1).
+	r.cumulative = make([]float64, len(variants))
+	sum := 0.0
+	for i, v := range r.variants {
+		if total == 0 {
+			sum += 1 / float64(len(variants))
+		} else {
+			sum += v.Weight / total
+		}
+		r.cumulative[i] = sum
+	}
+	r.cumulative[len(variants)-1] = 1
+	return r, nil
+}
+
+// Choose returns the variant for routing key, or a random variant by weight
+// when key is empty.
+func (r *Router) Choose(key string) Variant {
+	return r.variants[r.choose(key)]
+}
+
+func (r *Router) choose(key string) int {
+	var point float64
+	if key == "" {
+		r.mu.Lock()
+		point = r.rand.Float64()
+		r.mu.Unlock()
+	} else {
+		sum := sha256.Sum256([]byte(r.experiment + "\x00" + key))
+		// Use the top 53 bits for a uniform float in [0, 1).
+		point = float64(binary.BigEndian.Uint64(sum[:8])>>11) / (1 << 53)
+	}
+	for i, bound := range r.cumulative {
+		if point < bound {
+			return i
+		}
+	}
+	return len(r.cumulative) - 1
+}
+
+// Run runs input on a variant and waits for the output. The prediction
+// reports the variant and the model that served it.
+func (r *Router) Run(ctx context.Context, input map[string]any, opts ...RunOption) (*Prediction, error) {
+	i, options := r.prepare(opts)
+	start := time.Now()
+	pred, err := r.client.run(ctx, r.variants[i].Model, input, options)
+	r.record(i, time.Since(start), err)
+	if err != nil {
+		return nil, err
+	}
+	pred.Variant = r.variants[i].Name
+	return pred, nil
+}
+
+// RunNoThrow is Run reporting failures in the result instead of an error.
+func (r *Router) RunNoThrow(ctx context.Context, input map[string]any, opts ...RunOption) *RunNoThrowResult {
+	i, options := r.prepare(opts)
+	start := time.Now()
+	result := r.client.runNoThrow(ctx, r.variants[i].Model, input, options)
+	var err error
+	if result.Outputs == nil {
+		err = errors.New(result.Detail.Error)
+	}
+	r.record(i, time.Since(start), err)
+	result.Detail.Variant = r.variants[i].Name
+	return result
+}
+
+// prepare chooses a variant for a run and returns its index and options.
+func (r *Router) prepare(opts []RunOption) (int, *RunOptions) {
+	options := r.client.newRunOptions(opts)
+	i := r.choose(options.RoutingKey)
+	for _, opt := range r.variants[i].Options {
+		opt(options)
+	}
+	return i, options
+}
+
+func (r *Router) record(i int, latency time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s := &r.stats[i]
+	s.Requests++
+	if err != nil {
+		s.Failures++
+		s.LastError = err.Error()
+		return
+	}
+	s.totalLatency += latency
+	if completed := s.Requests - s.Failures; completed > 0 {
+		s.MeanLatency = s.totalLatency / time.Duration(completed)
+	}
+	if latency > s.MaxLatency {
+		s.MaxLatency = latency
+	}
+}
+
+// Stats returns a snapshot of the stats of every variant, in the order the
+// variants were given.
+func (r *Router) Stats() []VariantStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]VariantStats(nil), r.stats...)
+}
+
+// ResetStats clears the stats of every variant.
+func (r *Router) ResetStats() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, v := range r.variants {
+		r.stats[i] = VariantStats{Variant: v.Name, Model: v.Model}
+	}
+}
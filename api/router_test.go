@@ -0,0 +1,146 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRouterChoose(t *testing.T) {
+	variants := []Variant{
+		{Name: "control", Model: "model-a", Weight: 3},
+		{Name: "candidate", Model: "model-b", Weight: 1},
+	}
+	router, err := NewRouter(NewClient(WithAPIKey("test-key")), variants)
+	if err != nil {
+		t.Fatalf("NewRouter error: %v", err)
+	}
+
+	// Routing keys always get the same variant.
+	for i := 0; i < 20; i++ {
+		key := fmt.Sprintf("user-%d", i)
+		if first, again := router.Choose(key), router.Choose(key); first.Name != again.Name {
+			t.Fatalf("key %s routed to %s and %s", key, first.Name, again.Name)
+		}
+	}
+
+	// Keys and random picks follow the weights.
+	const n = 20000
+	byKey, random := 0, 0
+	for i := 0; i < n; i++ {
+		if router.Choose(fmt.Sprintf("user-%d", i)).Name == "control" {
+			byKey++
+		}
+		if router.Choose("").Name == "control" {
+			random++
+		}
+	}
+	for name, count := range map[string]int{"by key": byKey, "random": random} {
+		if share := float64(count) / n; math.Abs(share-0.75) > 0.02 {
+			t.Errorf("%s: expected about 75%% control, got %.3f", name, share)
+		}
+	}
+
+	// Another experiment assigns keys independently.
+	other, _ := NewRouter(NewClient(WithAPIKey("test-key")), variants, WithExperimentName("other"))
+	same := 0
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("user-%d", i)
+		if router.Choose(key).Name == other.Choose(key).Name {
+			same++
+		}
+	}
+	if same == 1000 {
+		t.Error("expected experiment name to change assignments")
+	}
+}
+
+func TestRouterValidation(t *testing.T) {
+	client := NewClient(WithAPIKey("test-key"))
+	tests := []struct {
+		variants []Variant
+		want     string
+	}{
+		{nil, "at least one variant"},
+		{[]Variant{{Name: "a"}}, "has no model"},
+		{[]Variant{{Model: "m", Weight: -1}}, "negative weight"},
+		{[]Variant{{Model: "m"}, {Model: "m"}}, "duplicate variant name: m"},
+	}
+	for _, tt := range tests {
+		if _, err := NewRouter(client, tt.variants); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("expected error containing %q, got %v", tt.want, err)
+		}
+	}
+
+	// Zero weights split traffic evenly.
+	router, err := NewRouter(client, []Variant{{Model: "a"}, {Model: "b"}})
+	if err != nil {
+		t.Fatalf("NewRouter error: %v", err)
+	}
+	if router.cumulative[0] != 0.5 {
+		t.Errorf("expected an even split, got %v", router.cumulative)
+	}
+}
+
+func TestRouterRunRecordsVariantAndStats(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/model-a", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"code":200,"data":{"id":"req-a","status":"completed","outputs":["https://example.com/a.png"]}}`))
+	})
+	mux.HandleFunc("/api/v3/model-b", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"code":400,"message":"bad input"}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := NewClient(WithAPIKey("test-key"), WithBaseURL(server.URL))
+	router, err := NewRouter(client, []Variant{
+		{Name: "control", Model: "model-a", Weight: 1, Options: []RunOption{WithSyncMode(true)}},
+		{Name: "candidate", Model: "model-b", Weight: 1, Options: []RunOption{WithSyncMode(true)}},
+	})
+	if err != nil {
+		t.Fatalf("NewRouter error: %v", err)
+	}
+
+	// Find keys routed to each variant.
+	keys := map[string]string{}
+	for i := 0; len(keys) < 2; i++ {
+		key := fmt.Sprintf("user-%d", i)
+		keys[router.Choose(key).Name] = key
+	}
+
+	ctx := context.Background()
+	pred, err := router.Run(ctx, map[string]any{"prompt": "Cat"}, WithRoutingKey(keys["control"]))
+	if err != nil {
+		t.Fatalf("run error: %v", err)
+	}
+	if pred.Variant != "control" || pred.Model != "model-a" || pred.ID != "req-a" {
+		t.Errorf("unexpected prediction: %+v", pred)
+	}
+
+	result := router.RunNoThrow(ctx, map[string]any{"prompt": "Cat"}, WithRoutingKey(keys["candidate"]))
+	if result.Outputs != nil || result.Detail.Variant != "candidate" || result.Detail.Model != "model-b" {
+		t.Errorf("unexpected result: %+v", result.Detail)
+	}
+	if _, err := router.Run(ctx, map[string]any{"prompt": "Cat"}, WithRoutingKey(keys["candidate"])); err == nil {
+		t.Error("expected candidate to fail")
+	}
+
+	stats := router.Stats()
+	if stats[0].Variant != "control" || stats[0].Requests != 1 || stats[0].Failures != 0 || stats[0].MeanLatency <= 0 {
+		t.Errorf("unexpected control stats: %+v", stats[0])
+	}
+	if stats[1].Requests != 2 || stats[1].Failures != 2 || stats[1].FailureRate() != 1 || !strings.Contains(stats[1].LastError, "HTTP 400") {
+		t.Errorf("unexpected candidate stats: %+v", stats[1])
+	}
+
+	router.ResetStats()
+	if stats := router.Stats(); stats[0].Requests != 0 || stats[1].Failures != 0 {
+		t.Errorf("expected stats to be reset, got %+v", stats)
+	}
+}
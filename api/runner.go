@@ -0,0 +1,21 @@
+package api
+
+import "context"
+
+// Runner is the set of Client methods that application code usually depends on.
+//
+// Accept a Runner instead of a *Client to be able to substitute a fake in
+// tests; see the apitest package for an in-memory implementation.
+type Runner interface {
+	Run(model string, input map[string]any, opts ...RunOption) (map[string]any, error)
+	RunNoThrow(model string, input map[string]any, opts ...RunOption) *RunNoThrowResult
+	RunContext(ctx context.Context, model string, input map[string]any, opts ...RunOption) (map[string]any, error)
+	RunNoThrowContext(ctx context.Context, model string, input map[string]any, opts ...RunOption) *RunNoThrowResult
+	Upload(file string, opts ...UploadOption) (string, error)
+	Submit(model string, input map[string]any, opts ...RunOption) (string, error)
+	Wait(taskID string, opts ...RunOption) (map[string]any, error)
+	GetPrediction(taskID string) (*Prediction, error)
+	Cancel(taskID string) error
+}
+
+var _ Runner = (*Client)(nil)
@@ -0,0 +1,245 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrTaskNotFound is returned by GetPrediction and Wait when the API no longer
+// knows a task, because its ID is wrong or it expired. Check for it with
+// errors.Is.
+var ErrTaskNotFound = errors.New("task not found or expired")
+
+// Submit submits a prediction without waiting for it to finish.
+//
+// Sync mode is ignored: the task is always submitted asynchronously and its
+// ID is returned so the caller can Wait on it or Cancel it later.
+//
+// Example:
+//
+//	taskID, err := client.Submit("wavespeed-ai/z-image/turbo", map[string]any{"prompt": "Cat"})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	output, err := client.Wait(taskID, api.WithTimeout(600))
+func (c *Client) Submit(model string, input map[string]any, opts ...RunOption) (string, error) {
+	options := c.newRunOptions(opts)
+	taskRetries := options.MaxRetries
+
+	if err := c.validateInput(context.Background(), model, input, options); err != nil {
+		return "", err
+	}
+
+	var lastError error
+	for attempt := 0; attempt <= taskRetries; attempt++ {
+		requestID, _, err := c.submit(context.Background(), model, input, false, options.Timeout, options.IdempotencyKey)
+		if err == nil {
+			return requestID, nil
+		}
+
+		lastError = err
+		if !c.isRetryableError(err) || attempt >= taskRetries {
+			return "", err
+		}
+
+		delay := c.retryInterval * float64(attempt+1)
+		c.logf("Task attempt %d/%d failed: %v\n", attempt+1, taskRetries+1, err)
+		c.logf("Retrying in %.1f seconds...\n", delay)
+		time.Sleep(time.Duration(delay * float64(time.Second)))
+	}
+
+	return "", lastError
+}
+
+// Wait polls a previously submitted task until it completes, fails or the
+// timeout set with WithTimeout is reached.
+//
+// Only the Timeout, PollInterval, PollStrategy and PollTimeout run options
+// are used.
+func (c *Client) Wait(taskID string, opts ...RunOption) (map[string]any, error) {
+	options := c.newRunOptions(opts)
+	return c.wait(context.Background(), taskID, "", options)
+}
+
+// GetPrediction fetches the current state of a task without waiting for it.
+//
+// Example:
+//
+//	pred, err := client.GetPrediction(taskID)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	fmt.Println(pred.Status) // "created", "processing", "completed" or "failed"
+func (c *Client) GetPrediction(taskID string) (*Prediction, error) {
+	return c.getPrediction(context.Background(), taskID)
+}
+
+func (c *Client) getPrediction(ctx context.Context, taskID string) (*Prediction, error) {
+	result, _, err := c.pollResult(ctx, taskID, time.Duration(c.connectionTimeout*float64(time.Second)))
+	if err != nil {
+		return nil, err
+	}
+
+	data, ok := result["data"]
+	if !ok {
+		return nil, errors.New("invalid response format")
+	}
+
+	// Round-trip through JSON to get the typed view of the response data.
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	var pred Prediction
+	if err := json.Unmarshal(raw, &pred); err != nil {
+		return nil, err
+	}
+	if pred.ID == "" {
+		pred.ID = taskID
+	}
+	switch pred.Status {
+	case "completed":
+		c.finishTask(taskID, true)
+		c.recordResult(taskID, TaskCompleted, pred.Outputs, "")
+	case "failed":
+		c.finishTask(taskID, true)
+		c.recordResult(taskID, TaskFailed, nil, pred.Error)
+	}
+	return &pred, nil
+}
+
+// Attach waits for a task that was submitted elsewhere, such as one from a
+// sync mode timeout or a support ticket, and reports it like RunNoThrow.
+//
+// taskIDOrURL is either a task ID or a prediction URL such as
+// https://api.wavespeed.ai/api/v3/predictions/{id}/result. Only the task ID is
+// taken from a URL: the request goes to the client's own endpoint so the API
+// key is never sent to a host from the URL. Only the Timeout and poll run
+// options are used.
+//
+// With WithAPIKeys, the task is polled with the key that submitted it if this
+// client or its task store knows it, and otherwise with the next key in
+// rotation, which must belong to the account that owns the task.
+//
+// Example:
+//
+//	result := client.Attach(ctx, "https://api.wavespeed.ai/api/v3/predictions/abc123/result")
+//	if result.Outputs == nil {
+//	    fmt.Println("Failed:", result.Detail.Error)
+//	}
+func (c *Client) Attach(ctx context.Context, taskIDOrURL string, opts ...RunOption) *RunNoThrowResult {
+	taskID, err := ParseTaskID(taskIDOrURL)
+	if err != nil {
+		return &RunNoThrowResult{
+			Detail: RunDetail{TaskID: "unknown", Status: "failed", Error: err.Error()},
+		}
+	}
+
+	pred, err := c.getPrediction(ctx, taskID)
+	if err != nil {
+		if errors.Is(err, ErrTaskNotFound) {
+			c.finishTask(taskID, true)
+			c.recordResult(taskID, TaskFailed, nil, err.Error())
+		}
+		return &RunNoThrowResult{
+			Detail: RunDetail{TaskID: taskID, Status: "failed", Error: err.Error()},
+		}
+	}
+
+	detail := RunDetail{
+		TaskID:    taskID,
+		Status:    pred.Status,
+		Model:     pred.Model,
+		CreatedAt: pred.CreatedAt,
+		ResultURL: pred.URLs["get"],
+	}
+	switch pred.Status {
+	case "completed":
+		outputs := pred.Outputs
+		if outputs == nil {
+			outputs = []any{}
+		}
+		return &RunNoThrowResult{Outputs: outputs, Detail: detail}
+	case "failed":
+		errorMsg := "Unknown error"
+		if pred.Error != "" {
+			errorMsg = pred.Error
+		}
+		detail.Error = fmt.Sprintf("prediction failed (task_id: %s): %s", taskID, errorMsg)
+		return &RunNoThrowResult{Detail: detail}
+	}
+
+	result := c.waitNoThrow(ctx, taskID, pred.Model, c.newRunOptions(opts))
+	result.Detail.CreatedAt = detail.CreatedAt
+	result.Detail.ResultURL = detail.ResultURL
+	return result
+}
+
+// ParseTaskID returns the task ID in ref, which is either a bare task ID or a
+// prediction URL such as https://api.wavespeed.ai/api/v3/predictions/{id}/result.
+func ParseTaskID(ref string) (string, error) {
+	ref = strings.TrimSpace(ref)
+	if !strings.Contains(ref, "://") {
+		if ref == "" || strings.ContainsAny(ref, "/?# ") {
+			return "", fmt.Errorf("invalid task ID: %q", ref)
+		}
+		return ref, nil
+	}
+
+	u, err := url.Parse(ref)
+	if err != nil {
+		return "", fmt.Errorf("invalid task URL: %w", err)
+	}
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	for i := 0; i < len(segments)-1; i++ {
+		if segments[i] == "predictions" && segments[i+1] != "" {
+			return segments[i+1], nil
+		}
+	}
+	return "", fmt.Errorf("no task ID in URL: %s", ref)
+}
+
+// Cancel asks the API to cancel a task that has not finished yet.
+func (c *Client) Cancel(taskID string) error {
+	url := c.taskBaseURL(taskID) + "/api/v3/predictions/" + taskID + "/cancel"
+
+	headers, err := c.taskHeaders(taskID)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(c.connectionTimeout*float64(time.Second)))
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, nil)
+	if err != nil {
+		return err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	client := &http.Client{
+		Timeout: time.Duration(c.connectionTimeout * float64(time.Second)),
+	}
+	resp, err := c.do(client, req)
+	if err != nil {
+		return fmt.Errorf("failed to cancel task %s: %w", taskID, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		bodyText, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to cancel task %s: HTTP %d: %s", taskID, resp.StatusCode, string(bodyText))
+	}
+	c.finishTask(taskID, true)
+	c.recordResult(taskID, TaskCanceled, nil, "")
+	return nil
+}
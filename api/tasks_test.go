@@ -0,0 +1,203 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSubmitReturnsTaskID(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/wavespeed-ai/z-image/turbo", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"code":200,"data":{"id":"req-123","status":"created"}}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := NewClient(WithAPIKey("test-key"), WithBaseURL(server.URL))
+	taskID, err := client.Submit("wavespeed-ai/z-image/turbo", map[string]any{"prompt": "test"}, WithSyncMode(true))
+	if err != nil {
+		t.Fatalf("submit error: %v", err)
+	}
+	if taskID != "req-123" {
+		t.Errorf("expected taskID=req-123, got %s", taskID)
+	}
+}
+
+func TestWaitReturnsOutputs(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/predictions/req-123/result", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"code":200,"data":{"status":"completed","outputs":["https://example.com/out.png"]}}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := NewClient(WithAPIKey("test-key"), WithBaseURL(server.URL))
+	result, err := client.Wait("req-123", WithPollInterval(0.01))
+	if err != nil {
+		t.Fatalf("wait error: %v", err)
+	}
+	outputs, ok := result["outputs"].([]any)
+	if !ok || len(outputs) != 1 {
+		t.Fatalf("expected 1 output, got %+v", result)
+	}
+}
+
+func TestCancel(t *testing.T) {
+	var method string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/predictions/req-123/cancel", func(w http.ResponseWriter, r *http.Request) {
+		method = r.Method
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"code":200,"message":"success"}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := NewClient(WithAPIKey("test-key"), WithBaseURL(server.URL))
+	if err := client.Cancel("req-123"); err != nil {
+		t.Fatalf("cancel error: %v", err)
+	}
+	if method != "POST" {
+		t.Errorf("expected POST, got %s", method)
+	}
+}
+
+func TestCancelHTTPError(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/predictions/req-123/cancel", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"task not found"}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := NewClient(WithAPIKey("test-key"), WithBaseURL(server.URL))
+	err := client.Cancel("req-123")
+	if err == nil {
+		t.Fatal("expected error for HTTP 404")
+	}
+	if !strings.Contains(err.Error(), "HTTP 404") {
+		t.Errorf("expected 'HTTP 404' in error, got: %v", err)
+	}
+}
+
+func TestGetPrediction(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/predictions/req-123/result", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"code":200,"data":{"id":"req-123","model":"wavespeed-ai/z-image/turbo","status":"processing","outputs":[],"created_at":"2025-01-01T00:00:00Z"}}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := NewClient(WithAPIKey("test-key"), WithBaseURL(server.URL))
+	pred, err := client.GetPrediction("req-123")
+	if err != nil {
+		t.Fatalf("GetPrediction error: %v", err)
+	}
+	if pred.ID != "req-123" || pred.Status != "processing" || pred.Model != "wavespeed-ai/z-image/turbo" {
+		t.Errorf("unexpected prediction: %+v", pred)
+	}
+	if pred.CreatedAt != "2025-01-01T00:00:00Z" {
+		t.Errorf("expected created_at, got %s", pred.CreatedAt)
+	}
+}
+
+func TestParseTaskID(t *testing.T) {
+	cases := map[string]string{
+		"req-123":    "req-123",
+		" req-123\n": "req-123",
+		"https://api.wavespeed.ai/api/v3/predictions/req-123/result": "req-123",
+		"https://api.wavespeed.ai/api/v3/predictions/req-123":        "req-123",
+	}
+	for ref, want := range cases {
+		got, err := ParseTaskID(ref)
+		if err != nil || got != want {
+			t.Errorf("ParseTaskID(%q) = %q, %v; want %q", ref, got, err, want)
+		}
+	}
+
+	for _, ref := range []string{"", "a/b", "https://example.com/download/out.png"} {
+		if _, err := ParseTaskID(ref); err == nil {
+			t.Errorf("ParseTaskID(%q): expected error", ref)
+		}
+	}
+}
+
+func TestAttachWaitsForTask(t *testing.T) {
+	polls := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/predictions/req-123/result", func(w http.ResponseWriter, r *http.Request) {
+		polls++
+		if polls < 3 {
+			w.Write([]byte(`{"code":200,"data":{"id":"req-123","model":"wavespeed-ai/z-image/turbo","status":"processing","created_at":"2025-01-01T00:00:00Z"}}`))
+			return
+		}
+		w.Write([]byte(`{"code":200,"data":{"id":"req-123","status":"completed","outputs":["https://example.com/out.png"]}}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := NewClient(WithAPIKey("test-key"), WithBaseURL(server.URL))
+	// The URL's host is ignored; the client's own endpoint is polled.
+	result := client.Attach(context.Background(), "https://elsewhere.example.com/api/v3/predictions/req-123/result", WithPollInterval(0.01))
+	if len(result.Outputs) != 1 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	d := result.Detail
+	if d.TaskID != "req-123" || d.Status != "completed" || d.Model != "wavespeed-ai/z-image/turbo" || d.CreatedAt == "" {
+		t.Errorf("unexpected detail: %+v", d)
+	}
+}
+
+func TestAttachTerminalAndErrors(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/predictions/req-failed/result", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"code":200,"data":{"id":"req-failed","status":"failed","error":"NSFW content"}}`))
+	})
+	mux.HandleFunc("/api/v3/predictions/req-missing/result", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("not found"))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := NewClient(WithAPIKey("test-key"), WithBaseURL(server.URL))
+
+	result := client.Attach(context.Background(), "req-failed")
+	if result.Outputs != nil || result.Detail.Status != "failed" || !strings.Contains(result.Detail.Error, "NSFW content") {
+		t.Errorf("unexpected failed result: %+v", result)
+	}
+
+	result = client.Attach(context.Background(), "req-missing")
+	if result.Outputs != nil || result.Detail.TaskID != "req-missing" || !strings.Contains(result.Detail.Error, "HTTP 404") {
+		t.Errorf("unexpected missing result: %+v", result)
+	}
+
+	result = client.Attach(context.Background(), "not/a/task")
+	if result.Detail.TaskID != "unknown" || result.Detail.Error == "" {
+		t.Errorf("unexpected invalid ref result: %+v", result)
+	}
+}
+
+func TestAttachCanceledContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"code":200,"data":{"id":"req-123","status":"processing"}}`))
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	client := NewClient(WithAPIKey("test-key"), WithBaseURL(server.URL))
+	result := client.Attach(ctx, "req-123", WithPollInterval(0.01))
+	if result.Outputs != nil || !strings.Contains(result.Detail.Error, "context deadline exceeded") {
+		t.Errorf("expected context error, got %+v", result)
+	}
+}
@@ -0,0 +1,428 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// TaskState is the state of a task recorded in a TaskStore.
+type TaskState string
+
+const (
+	// TaskPending means the task was submitted and has not finished yet.
+	TaskPending TaskState = "pending"
+	// TaskCompleted means the task finished with outputs.
+	TaskCompleted TaskState = "completed"
+	// TaskFailed means the task finished with an error.
+	TaskFailed TaskState = "failed"
+	// TaskCanceled means the task was canceled through this client.
+	TaskCanceled TaskState = "canceled"
+)
+
+// TaskRecord is a submission recorded in a TaskStore.
+type TaskRecord struct {
+	TaskID         string    `json:"task_id"`
+	Model          string    `json:"model"`
+	InputHash      string    `json:"input_hash"`
+	IdempotencyKey string    `json:"idempotency_key,omitempty"`
+	State          TaskState `json:"state"`
+	Outputs        []any     `json:"outputs,omitempty"`
+	Error          string    `json:"error,omitempty"`
+	SubmittedAt    time.Time `json:"submitted_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+
+	// KeyID identifies the WithAPIKeys key that submitted the task, so that a
+	// new client can poll it with the same key. It is not the key itself.
+	KeyID string `json:"key_id,omitempty"`
+}
+
+// TaskStore records submitted tasks so that they can be picked up again after
+// a restart with ResumePending. Implementations must be safe for concurrent use.
+type TaskStore interface {
+	// Save inserts record or replaces the record with the same TaskID.
+	Save(record TaskRecord) error
+	// Load returns the record for taskID, or nil if there is none.
+	Load(taskID string) (*TaskRecord, error)
+	// LoadByIdempotencyKey returns the most recent record submitted with key,
+	// or nil if there is none.
+	LoadByIdempotencyKey(key string) (*TaskRecord, error)
+	// Pending returns the records in TaskPending state, oldest first.
+	Pending() ([]TaskRecord, error)
+}
+
+// WithTaskStore records every submission and its outcome in store.
+//
+// Example:
+//
+//	store, err := api.OpenFileTaskStore("tasks.jsonl")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer store.Close()
+//
+//	client := api.NewClient(api.WithTaskStore(store))
+//	results, err := client.ResumePending(ctx) // tasks left over from a previous run
+func WithTaskStore(store TaskStore) ClientOption {
+	return func(c *Client) {
+		c.store = store
+	}
+}
+
+// defaultResumeConcurrency is the number of tasks ResumePending waits for at
+// once unless WithResumeConcurrency says otherwise. It matches the default
+// worker count of the batch command.
+const defaultResumeConcurrency = 4
+
+// WithResumeConcurrency sets how many pending tasks ResumePending waits for
+// at once. The default is 4.
+func WithResumeConcurrency(n int) ClientOption {
+	return func(c *Client) {
+		c.resumeConcurrency = n
+	}
+}
+
+// ResumePending waits for every task the store still records as pending,
+// such as tasks submitted before the process restarted, and returns their
+// results in submission order. Only the Timeout and poll run options are used.
+//
+// Tasks the API no longer knows, because they expired, are recorded as
+// failed so they are not resumed again.
+func (c *Client) ResumePending(ctx context.Context, opts ...RunOption) ([]*RunNoThrowResult, error) {
+	if c.store == nil {
+		return nil, errors.New("no task store configured; use WithTaskStore")
+	}
+	records, err := c.store.Pending()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load pending tasks: %w", err)
+	}
+
+	concurrency := c.resumeConcurrency
+	if concurrency < 1 {
+		concurrency = defaultResumeConcurrency
+	}
+	sem := make(chan struct{}, concurrency)
+
+	results := make([]*RunNoThrowResult, len(records))
+	var wg sync.WaitGroup
+	for i, record := range records {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, record TaskRecord) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			result := c.Attach(ctx, record.TaskID, opts...)
+			if result.Detail.Model == "" {
+				result.Detail.Model = record.Model
+			}
+			results[i] = result
+		}(i, record)
+	}
+	wg.Wait()
+	return results, nil
+}
+
+// hashInput returns a stable hash of a model input. encoding/json sorts map
+// keys, so equal inputs hash equally.
+func hashInput(input map[string]any) string {
+	data, err := json.Marshal(input)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// recordSubmission adds a newly submitted task to the store, if any. apiKey
+// is the pooled key that submitted it, or "" without a key pool.
+func (c *Client) recordSubmission(taskID, model string, input map[string]any, idempotencyKey, apiKey string) {
+	if c.store == nil {
+		return
+	}
+	now := time.Now().UTC()
+	record := TaskRecord{
+		TaskID:         taskID,
+		Model:          model,
+		InputHash:      hashInput(input),
+		IdempotencyKey: idempotencyKey,
+		KeyID:          keyID(apiKey),
+		State:          TaskPending,
+		SubmittedAt:    now,
+		UpdatedAt:      now,
+	}
+	if err := c.store.Save(record); err != nil {
+		c.logf("Failed to record task %s: %v\n", taskID, err)
+	}
+}
+
+// recordResult updates the stored state of taskID. Tasks the store does not
+// know about are ignored.
+func (c *Client) recordResult(taskID string, state TaskState, outputs []any, errorMsg string) {
+	if c.store == nil {
+		return
+	}
+	record, err := c.store.Load(taskID)
+	if err == nil && record != nil {
+		record.State = state
+		record.Outputs = outputs
+		record.Error = errorMsg
+		record.UpdatedAt = time.Now().UTC()
+		err = c.store.Save(*record)
+	}
+	if err != nil {
+		c.logf("Failed to record task %s: %v\n", taskID, err)
+	}
+}
+
+// MemoryTaskStore keeps task records in memory. It does not survive a
+// restart, but lets ResumePending recover tasks abandoned by a timeout.
+type MemoryTaskStore struct {
+	mu      sync.Mutex
+	records map[string]TaskRecord
+}
+
+// NewMemoryTaskStore returns an empty in-memory task store.
+func NewMemoryTaskStore() *MemoryTaskStore {
+	return &MemoryTaskStore{records: make(map[string]TaskRecord)}
+}
+
+// Save inserts or replaces record.
+func (s *MemoryTaskStore) Save(record TaskRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[record.TaskID] = record
+	return nil
+}
+
+// Load returns the record for taskID, or nil.
+func (s *MemoryTaskStore) Load(taskID string) (*TaskRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, ok := s.records[taskID]
+	if !ok {
+		return nil, nil
+	}
+	return &record, nil
+}
+
+// LoadByIdempotencyKey returns the latest record submitted with key, or nil.
+func (s *MemoryTaskStore) LoadByIdempotencyKey(key string) (*TaskRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return findByIdempotencyKey(s.records, key), nil
+}
+
+// Pending returns the pending records, oldest first.
+func (s *MemoryTaskStore) Pending() ([]TaskRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return pendingRecords(s.records), nil
+}
+
+// FileTaskStore is a TaskStore backed by an append-only JSONL journal. Every
+// change is appended and synced to disk, and the latest line for each task
+// wins when the journal is opened again.
+type FileTaskStore struct {
+	path string
+
+	mu      sync.Mutex
+	file    *os.File
+	records map[string]TaskRecord
+}
+
+// OpenFileTaskStore opens or creates the journal at path and loads the
+// records in it. A partially written last line, as left by a crash, is ignored.
+func OpenFileTaskStore(path string) (*FileTaskStore, error) {
+	records, err := readJournal(path)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open task journal: %w", err)
+	}
+	return &FileTaskStore{path: path, file: file, records: records}, nil
+}
+
+func readJournal(path string) (map[string]TaskRecord, error) {
+	records := make(map[string]TaskRecord)
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return records, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open task journal: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var record TaskRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil || record.TaskID == "" {
+			continue
+		}
+		records[record.TaskID] = record
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read task journal: %w", err)
+	}
+	return records, nil
+}
+
+// Save appends record to the journal.
+func (s *FileTaskStore) Save(record TaskRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return errors.New("task journal is closed")
+	}
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write task journal: %w", err)
+	}
+	if err := s.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync task journal: %w", err)
+	}
+	s.records[record.TaskID] = record
+	return nil
+}
+
+// Load returns the latest record for taskID, or nil.
+func (s *FileTaskStore) Load(taskID string) (*TaskRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, ok := s.records[taskID]
+	if !ok {
+		return nil, nil
+	}
+	return &record, nil
+}
+
+// LoadByIdempotencyKey returns the latest record submitted with key, or nil.
+func (s *FileTaskStore) LoadByIdempotencyKey(key string) (*TaskRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return findByIdempotencyKey(s.records, key), nil
+}
+
+// Pending returns the pending records, oldest first.
+func (s *FileTaskStore) Pending() ([]TaskRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return pendingRecords(s.records), nil
+}
+
+// Compact rewrites the journal with only the latest record for each task,
+// dropping finished tasks last updated before olderThan ago.
+func (s *FileTaskStore) Compact(olderThan time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return errors.New("task journal is closed")
+	}
+
+	cutoff := time.Now().Add(-olderThan)
+	kept := make(map[string]TaskRecord)
+	var lines []byte
+	for _, record := range sortedRecords(s.records) {
+		if record.State != TaskPending && record.UpdatedAt.Before(cutoff) {
+			continue
+		}
+		line, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		lines = append(append(lines, line...), '\n')
+		kept[record.TaskID] = record
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to compact task journal: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(lines); err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), s.path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to compact task journal: %w", err)
+	}
+
+	file, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to reopen task journal: %w", err)
+	}
+	s.file.Close()
+	s.file = file
+	s.records = kept
+	return nil
+}
+
+// Close closes the journal file.
+func (s *FileTaskStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+func sortedRecords(records map[string]TaskRecord) []TaskRecord {
+	sorted := make([]TaskRecord, 0, len(records))
+	for _, record := range records {
+		sorted = append(sorted, record)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if !sorted[i].SubmittedAt.Equal(sorted[j].SubmittedAt) {
+			return sorted[i].SubmittedAt.Before(sorted[j].SubmittedAt)
+		}
+		return sorted[i].TaskID < sorted[j].TaskID
+	})
+	return sorted
+}
+
+func pendingRecords(records map[string]TaskRecord) []TaskRecord {
+	var pending []TaskRecord
+	for _, record := range sortedRecords(records) {
+		if record.State == TaskPending {
+			pending = append(pending, record)
+		}
+	}
+	return pending
+}
+
+func findByIdempotencyKey(records map[string]TaskRecord, key string) *TaskRecord {
+	var found *TaskRecord
+	for _, record := range sortedRecords(records) {
+		if key != "" && record.IdempotencyKey == key {
+			record := record
+			found = &record
+		}
+	}
+	return found
+}
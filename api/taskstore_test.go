@@ -0,0 +1,186 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFileTaskStoreJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.jsonl")
+	store, err := OpenFileTaskStore(path)
+	if err != nil {
+		t.Fatalf("open error: %v", err)
+	}
+
+	now := time.Now().UTC()
+	store.Save(TaskRecord{TaskID: "a", Model: "m", State: TaskPending, SubmittedAt: now})
+	store.Save(TaskRecord{TaskID: "b", Model: "m", State: TaskPending, SubmittedAt: now.Add(time.Second)})
+	store.Save(TaskRecord{TaskID: "a", Model: "m", State: TaskCompleted, SubmittedAt: now, UpdatedAt: now.Add(-time.Hour)})
+	store.Close()
+
+	// Simulate a crash in the middle of writing a line.
+	f, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o600)
+	f.Write([]byte(`{"task_id":"c","sta`))
+	f.Close()
+
+	store, err = OpenFileTaskStore(path)
+	if err != nil {
+		t.Fatalf("reopen error: %v", err)
+	}
+	defer store.Close()
+
+	pending, _ := store.Pending()
+	if len(pending) != 1 || pending[0].TaskID != "b" {
+		t.Errorf("unexpected pending records: %+v", pending)
+	}
+	if record, _ := store.Load("a"); record == nil || record.State != TaskCompleted {
+		t.Errorf("expected latest record to win, got %+v", record)
+	}
+
+	if err := store.Compact(time.Minute); err != nil {
+		t.Fatalf("compact error: %v", err)
+	}
+	if record, _ := store.Load("a"); record != nil {
+		t.Errorf("expected old finished record to be dropped, got %+v", record)
+	}
+	store.Save(TaskRecord{TaskID: "d", State: TaskPending, SubmittedAt: now.Add(2 * time.Second)})
+	store.Close()
+
+	records, err := readJournal(path)
+	if err != nil || len(records) != 2 || records["b"].TaskID == "" || records["d"].TaskID == "" {
+		t.Errorf("unexpected journal after compaction: %+v, %v", records, err)
+	}
+}
+
+func TestTaskStoreRecordsSubmissions(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/wavespeed-ai/z-image/turbo", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"code":200,"data":{"id":"req-123"}}`))
+	})
+	mux.HandleFunc("/api/v3/predictions/req-123/result", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"code":200,"data":{"status":"failed","error":"NSFW content"}}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	store := NewMemoryTaskStore()
+	client := NewClient(WithAPIKey("test-key"), WithBaseURL(server.URL), WithTaskStore(store))
+
+	input := map[string]any{"prompt": "Cat", "seed": 1}
+	taskID, err := client.Submit("wavespeed-ai/z-image/turbo", input)
+	if err != nil {
+		t.Fatalf("submit error: %v", err)
+	}
+	record, _ := store.Load(taskID)
+	if record == nil || record.State != TaskPending || record.Model != "wavespeed-ai/z-image/turbo" {
+		t.Fatalf("unexpected record after submit: %+v", record)
+	}
+	if record.InputHash != hashInput(map[string]any{"seed": 1, "prompt": "Cat"}) || record.InputHash == "" {
+		t.Errorf("expected a stable input hash, got %q", record.InputHash)
+	}
+
+	client.Wait(taskID, WithPollInterval(0.01))
+	record, _ = store.Load(taskID)
+	if record.State != TaskFailed || record.Error != "NSFW content" {
+		t.Errorf("unexpected record after wait: %+v", record)
+	}
+}
+
+func TestResumePendingAfterRestart(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/wavespeed-ai/z-image/turbo", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"code":200,"data":{"id":"req-123"}}`))
+	})
+	mux.HandleFunc("/api/v3/predictions/req-123/result", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"code":200,"data":{"id":"req-123","status":"completed","outputs":["https://example.com/out.png"]}}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "tasks.jsonl")
+	store, _ := OpenFileTaskStore(path)
+	client := NewClient(WithAPIKey("test-key"), WithBaseURL(server.URL), WithTaskStore(store))
+	if _, err := client.Submit("wavespeed-ai/z-image/turbo", nil); err != nil {
+		t.Fatalf("submit error: %v", err)
+	}
+	store.Close()
+
+	// A new process opens the same journal.
+	store, _ = OpenFileTaskStore(path)
+	defer store.Close()
+	client = NewClient(WithAPIKey("test-key"), WithBaseURL(server.URL), WithTaskStore(store))
+	results, err := client.ResumePending(context.Background(), WithPollInterval(0.01))
+	if err != nil {
+		t.Fatalf("resume error: %v", err)
+	}
+	if len(results) != 1 || len(results[0].Outputs) != 1 || results[0].Detail.Model != "wavespeed-ai/z-image/turbo" {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+	if pending, _ := store.Pending(); len(pending) != 0 {
+		t.Errorf("expected no pending tasks after resuming, got %+v", pending)
+	}
+
+	if _, err := NewClient().ResumePending(context.Background()); err == nil {
+		t.Error("expected error without a task store")
+	}
+}
+
+func TestResumePendingConcurrencyAndExpiredTasks(t *testing.T) {
+	var mu sync.Mutex
+	running, peak := 0, 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/predictions/", func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "/expired/") {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code":404,"message":"task not found"}`))
+			return
+		}
+		mu.Lock()
+		running++
+		if running > peak {
+			peak = running
+		}
+		mu.Unlock()
+		time.Sleep(20 * time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+		w.Write([]byte(`{"code":200,"data":{"status":"completed","outputs":["https://example.com/out.png"]}}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	store := NewMemoryTaskStore()
+	for i := 0; i < 8; i++ {
+		store.Save(TaskRecord{TaskID: fmt.Sprintf("req-%d", i), State: TaskPending, SubmittedAt: time.Now()})
+	}
+	store.Save(TaskRecord{TaskID: "expired", State: TaskPending, SubmittedAt: time.Now()})
+
+	client := NewClient(WithAPIKey("test-key"), WithBaseURL(server.URL), WithTaskStore(store), WithResumeConcurrency(2))
+	results, err := client.ResumePending(context.Background(), WithPollInterval(0.01))
+	if err != nil {
+		t.Fatalf("resume error: %v", err)
+	}
+	if len(results) != 9 {
+		t.Fatalf("expected 9 results, got %d", len(results))
+	}
+	if peak > 2 {
+		t.Errorf("expected at most 2 tasks polled at once, got %d", peak)
+	}
+
+	record, _ := store.Load("expired")
+	if record.State != TaskFailed || !strings.Contains(record.Error, "HTTP 404") {
+		t.Errorf("expected the expired task to be recorded as failed, got %+v", record)
+	}
+	if pending, _ := store.Pending(); len(pending) != 0 {
+		t.Errorf("expected no pending tasks after resuming, got %+v", pending)
+	}
+}
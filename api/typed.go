@@ -0,0 +1,160 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// OutputDecoder is implemented by output types that decode task outputs
+// themselves. RunAs and RunTyped call DecodeOutputs on a pointer to the
+// output type instead of decoding the outputs as JSON.
+//
+// Example:
+//
+//	type Caption struct{ Text string }
+//
+//	func (c *Caption) DecodeOutputs(outputs []any) error {
+//	    c.Text = fmt.Sprint(outputs...)
+//	    return nil
+//	}
+type OutputDecoder interface {
+	DecodeOutputs(outputs []any) error
+}
+
+// RunAs runs model and decodes its outputs into T, returning the completed
+// prediction alongside for its task ID.
+//
+// input may be a map[string]any or any value that encodes to a JSON object,
+// such as a struct with JSON tags. The outputs are decoded as JSON into T:
+// a []string for URLs, or a struct or string for models that return a
+// single output, such as text and LLM models. A single string output holding
+// a JSON document is decoded from that document. T may implement
+// OutputDecoder to decode the outputs itself.
+//
+// Example:
+//
+//	type Answer struct {
+//	    Text string `json:"text"`
+//	}
+//
+//	answer, pred, err := api.RunAs[Answer](ctx, client, "wavespeed-ai/any-llm", map[string]any{"prompt": "Hi"})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	fmt.Println(pred.ID, answer.Text)
+func RunAs[T any](ctx context.Context, client *Client, model string, input any, opts ...RunOption) (T, *Prediction, error) {
+	var out T
+
+	params, err := inputMap(input)
+	if err != nil {
+		return out, nil, err
+	}
+	pred, err := client.run(ctx, model, params, client.newRunOptions(opts))
+	if err != nil {
+		return out, nil, err
+	}
+	if err := decodeOutputs(pred.rawOutputs(), &out); err != nil {
+		return out, pred, err
+	}
+	return out, pred, nil
+}
+
+// RunTyped runs model with a typed input and decodes its outputs into Out.
+//
+// The input is encoded through its JSON tags, so structs generated by
+// wavespeed-gen, hand-written structs and maps all work. The outputs are
+// decoded into Out as described for RunAs, e.g. into a []string of URLs.
+//
+// Example:
+//
+//	type Input struct {
+//	    Prompt string `json:"prompt"`
+//	    Seed   *int   `json:"seed,omitempty"`
+//	}
+//
+//	urls, err := api.RunTyped[Input, []string](client, "wavespeed-ai/z-image/turbo", Input{Prompt: "Cat"})
+func RunTyped[In, Out any](client Runner, model string, input In, opts ...RunOption) (Out, error) {
+	var out Out
+
+	params, err := inputMap(input)
+	if err != nil {
+		return out, err
+	}
+	result, err := client.Run(model, params, opts...)
+	if err != nil {
+		return out, err
+	}
+	if err := decodeOutputs(result["outputs"], &out); err != nil {
+		return out, err
+	}
+	return out, nil
+}
+
+// inputMap converts a model input to the map sent to the API.
+func inputMap(input any) (map[string]any, error) {
+	switch v := input.(type) {
+	case nil:
+		return map[string]any{}, nil
+	case map[string]any:
+		return v, nil
+	}
+
+	data, err := json.Marshal(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode input: %w", err)
+	}
+	var params map[string]any
+	if err := json.Unmarshal(data, &params); err != nil {
+		return nil, fmt.Errorf("input must encode to a JSON object: %w", err)
+	}
+	if params == nil {
+		params = map[string]any{}
+	}
+	return params, nil
+}
+
+// decodeOutputs decodes the outputs of a task into out. The outputs are
+// decoded as a whole first; a single output is then decoded on its own, and a
+// single string output as the JSON document it holds. Outputs that are not a
+// list, such as the text of an LLM, count as a single output.
+func decodeOutputs(outputs any, out any) error {
+	if outputs == nil {
+		outputs = []any{}
+	}
+	list := outputList(outputs)
+	if decoder, ok := out.(OutputDecoder); ok {
+		if err := decoder.DecodeOutputs(list); err != nil {
+			return fmt.Errorf("failed to decode outputs: %w", err)
+		}
+		return nil
+	}
+
+	data, err := json.Marshal(outputs)
+	if err != nil {
+		return fmt.Errorf("failed to decode outputs: %w", err)
+	}
+	if err = json.Unmarshal(data, out); err == nil {
+		return nil
+	}
+
+	if len(list) == 1 {
+		if single, marshalErr := json.Marshal(list[0]); marshalErr == nil && json.Unmarshal(single, out) == nil {
+			return nil
+		}
+		if text, ok := list[0].(string); ok && json.Unmarshal([]byte(text), out) == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("failed to decode outputs: %w", err)
+}
+
+// Ptr returns a pointer to v, for setting optional fields of generated input
+// structs.
+//
+// Example:
+//
+//	input := models.ZImageTurboInput{Prompt: "Cat", Seed: api.Ptr(42)}
+func Ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,157 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRunTyped(t *testing.T) {
+	var submitted map[string]any
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/wavespeed-ai/z-image/turbo", func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&submitted)
+		w.Write([]byte(`{"code":200,"data":{"id":"req-123"}}`))
+	})
+	mux.HandleFunc("/api/v3/predictions/req-123/result", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"code":200,"data":{"status":"completed","outputs":["https://example.com/a.png","https://example.com/b.png"]}}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	type input struct {
+		Prompt string `json:"prompt"`
+		Seed   *int   `json:"seed,omitempty"`
+		Size   string `json:"size,omitempty"`
+	}
+	client := NewClient(WithAPIKey("test-key"), WithBaseURL(server.URL))
+	urls, err := RunTyped[input, []string](client, "wavespeed-ai/z-image/turbo", input{Prompt: "Cat"}, WithPollInterval(0.01))
+	if err != nil {
+		t.Fatalf("run error: %v", err)
+	}
+	if len(urls) != 2 || urls[1] != "https://example.com/b.png" {
+		t.Errorf("unexpected outputs: %v", urls)
+	}
+	if len(submitted) != 1 || submitted["prompt"] != "Cat" {
+		t.Errorf("expected only set fields to be submitted, got %v", submitted)
+	}
+
+	// Outputs that do not fit Out are reported.
+	if _, err := RunTyped[input, []int](client, "wavespeed-ai/z-image/turbo", input{Prompt: "Cat"}, WithPollInterval(0.01)); err == nil || !strings.Contains(err.Error(), "failed to decode outputs") {
+		t.Errorf("expected decode error, got %v", err)
+	}
+	if _, err := RunTyped[[]string, []string](client, "wavespeed-ai/z-image/turbo", []string{"Cat"}); err == nil || !strings.Contains(err.Error(), "JSON object") {
+		t.Errorf("expected input encoding error, got %v", err)
+	}
+}
+
+type captionOutput struct {
+	Text string
+}
+
+func (c *captionOutput) DecodeOutputs(outputs []any) error {
+	if len(outputs) != 1 {
+		return fmt.Errorf("expected 1 output, got %d", len(outputs))
+	}
+	c.Text = strings.ToUpper(fmt.Sprint(outputs[0]))
+	return nil
+}
+
+func TestRunAs(t *testing.T) {
+	outputs := map[string]string{
+		"req-urls": `["https://example.com/a.png"]`,
+		"req-json": `["{\"text\":\"hello\",\"tokens\":2}"]`,
+		"req-text": `["hello"]`,
+		"req-raw":  `"{\"text\":\"raw\",\"tokens\":3}"`,
+		"req-obj":  `{"text":"object","tokens":4}`,
+	}
+	var next string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/wavespeed-ai/any-llm", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"code":200,"data":{"id":%q}}`, next)
+	})
+	mux.HandleFunc("/api/v3/predictions/", func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/v3/predictions/"), "/result")
+		fmt.Fprintf(w, `{"code":200,"data":{"status":"completed","outputs":%s}}`, outputs[id])
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := NewClient(WithAPIKey("test-key"), WithBaseURL(server.URL))
+	ctx := context.Background()
+	input := struct {
+		Prompt string `json:"prompt"`
+	}{"Hi"}
+
+	next = "req-urls"
+	urls, pred, err := RunAs[[]string](ctx, client, "wavespeed-ai/any-llm", input, WithPollInterval(0.01))
+	if err != nil {
+		t.Fatalf("run error: %v", err)
+	}
+	if len(urls) != 1 || urls[0] != "https://example.com/a.png" {
+		t.Errorf("unexpected outputs: %v", urls)
+	}
+	if pred.ID != "req-urls" || pred.Model != "wavespeed-ai/any-llm" || pred.Status != "completed" {
+		t.Errorf("unexpected prediction: %+v", pred)
+	}
+
+	// A single string output holding JSON decodes into a struct.
+	type answer struct {
+		Text   string `json:"text"`
+		Tokens int    `json:"tokens"`
+	}
+	next = "req-json"
+	got, _, err := RunAs[answer](ctx, client, "wavespeed-ai/any-llm", map[string]any{"prompt": "Hi"}, WithPollInterval(0.01))
+	if err != nil {
+		t.Fatalf("run error: %v", err)
+	}
+	if got.Text != "hello" || got.Tokens != 2 {
+		t.Errorf("unexpected answer: %+v", got)
+	}
+
+	// Outputs that are not a list decode too, and Run passes them through.
+	next = "req-raw"
+	got, _, err = RunAs[answer](ctx, client, "wavespeed-ai/any-llm", input, WithPollInterval(0.01))
+	if err != nil || got.Text != "raw" || got.Tokens != 3 {
+		t.Errorf("expected answer from text output, got %+v, %v", got, err)
+	}
+	next = "req-obj"
+	got, pred, err = RunAs[answer](ctx, client, "wavespeed-ai/any-llm", input, WithPollInterval(0.01))
+	if err != nil || got.Text != "object" || got.Tokens != 4 || len(pred.Outputs) != 1 {
+		t.Errorf("expected answer from object output, got %+v, %+v, %v", got, pred, err)
+	}
+	next = "req-raw"
+	result, err := client.Run("wavespeed-ai/any-llm", map[string]any{"prompt": "Hi"}, WithPollInterval(0.01))
+	if err != nil || result["outputs"] != `{"text":"raw","tokens":3}` {
+		t.Errorf("expected raw outputs from Run, got %v, %v", result, err)
+	}
+
+	// A single output decodes into a scalar.
+	next = "req-text"
+	text, _, err := RunAs[string](ctx, client, "wavespeed-ai/any-llm", input, WithPollInterval(0.01))
+	if err != nil || text != "hello" {
+		t.Errorf("expected text output, got %q, %v", text, err)
+	}
+
+	// Output types may decode outputs themselves.
+	caption, _, err := RunAs[captionOutput](ctx, client, "wavespeed-ai/any-llm", input, WithPollInterval(0.01))
+	if err != nil || caption.Text != "HELLO" {
+		t.Errorf("expected custom decoding, got %+v, %v", caption, err)
+	}
+
+	// The prediction is returned with a decode error.
+	_, pred, err = RunAs[int](ctx, client, "wavespeed-ai/any-llm", input, WithPollInterval(0.01))
+	if err == nil || !strings.Contains(err.Error(), "failed to decode outputs") || pred == nil || pred.ID != "req-text" {
+		t.Errorf("expected decode error with prediction, got %+v, %v", pred, err)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, _, err := RunAs[[]string](canceled, client, "wavespeed-ai/any-llm", input); err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
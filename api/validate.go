@@ -0,0 +1,257 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ValidationProblem is one way in which an input does not match a schema.
+type ValidationProblem struct {
+	// Path is a JSON pointer to the offending value, e.g. "/loras/0/scale".
+	// It is empty for the input as a whole.
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (p ValidationProblem) String() string {
+	if p.Path == "" {
+		return p.Message
+	}
+	return p.Path + ": " + p.Message
+}
+
+// ValidationError is returned by Run, RunNoThrow and Submit with
+// WithValidation when the input does not match the model's input schema.
+// It lists every problem found. Check for it with errors.As.
+type ValidationError struct {
+	Model    string
+	Problems []ValidationProblem
+}
+
+func (e *ValidationError) Error() string {
+	problems := make([]string, len(e.Problems))
+	for i, p := range e.Problems {
+		problems[i] = p.String()
+	}
+	return fmt.Sprintf("invalid input for model %s: %s", e.Model, strings.Join(problems, "; "))
+}
+
+// WithValidation checks the input against the model's input schema from the
+// model catalog before submitting it, and fails with a *ValidationError
+// instead of spending a round trip on a prediction that cannot succeed.
+//
+// Required fields, types, enums, numeric ranges, string and array lengths are
+// checked, and keys the schema does not declare are reported as unknown.
+//
+// Example:
+//
+//	_, err := client.Run(model, input, api.WithValidation())
+//	var invalid *api.ValidationError
+//	if errors.As(err, &invalid) {
+//	    for _, p := range invalid.Problems {
+//	        fmt.Println(p.Path, p.Message)
+//	    }
+//	}
+func WithValidation() RunOption {
+	return func(o *RunOptions) {
+		o.Validate = true
+	}
+}
+
+// validateInput checks input against the catalog schema of model when the
+// options ask for it. ctx bounds the catalog lookup.
+func (c *Client) validateInput(ctx context.Context, model string, input map[string]any, options *RunOptions) error {
+	if !options.Validate {
+		return nil
+	}
+	m, err := c.GetModel(ctx, model)
+	if err != nil {
+		return fmt.Errorf("failed to validate input: %w", err)
+	}
+	if m.InputSchema == nil {
+		return nil
+	}
+	if input == nil {
+		input = map[string]any{}
+	}
+	if problems := m.InputSchema.Validate(input); len(problems) > 0 {
+		return &ValidationError{Model: model, Problems: problems}
+	}
+	return nil
+}
+
+// Validate checks value against the schema and returns every problem found,
+// sorted by path. value may be any JSON-encodable Go value.
+//
+// Object schemas that declare properties reject undeclared keys unless
+// AdditionalProperties is true or a schema.
+func (s *Schema) Validate(value any) []ValidationProblem {
+	// Normalize to the types encoding/json decodes into.
+	data, err := json.Marshal(value)
+	if err != nil {
+		return []ValidationProblem{{Message: fmt.Sprintf("cannot encode input: %v", err)}}
+	}
+	var normalized any
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return []ValidationProblem{{Message: fmt.Sprintf("cannot encode input: %v", err)}}
+	}
+
+	var problems []ValidationProblem
+	s.validate("", normalized, &problems)
+	sort.SliceStable(problems, func(i, j int) bool {
+		return problems[i].Path < problems[j].Path
+	})
+	return problems
+}
+
+func (s *Schema) validate(path string, value any, problems *[]ValidationProblem) {
+	if s == nil {
+		return
+	}
+	report := func(format string, args ...any) {
+		*problems = append(*problems, ValidationProblem{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if s.Type != "" && !matchesType(s.Type, value) {
+		report("expected %s, got %s", s.Type, jsonType(value))
+		return
+	}
+
+	if len(s.Enum) > 0 && !inEnum(s.Enum, value) {
+		values := make([]string, len(s.Enum))
+		for i, v := range s.Enum {
+			encoded, _ := json.Marshal(v)
+			values[i] = string(encoded)
+		}
+		report("must be one of %s", strings.Join(values, ", "))
+	}
+
+	switch v := value.(type) {
+	case float64:
+		if s.Minimum != nil && v < *s.Minimum {
+			report("must be at least %g", *s.Minimum)
+		}
+		if s.Maximum != nil && v > *s.Maximum {
+			report("must be at most %g", *s.Maximum)
+		}
+	case string:
+		length := utf8.RuneCountInString(v)
+		if s.MinLength != nil && length < *s.MinLength {
+			report("must be at least %d characters long", *s.MinLength)
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			report("must be at most %d characters long", *s.MaxLength)
+		}
+	case []any:
+		if s.MinItems != nil && len(v) < *s.MinItems {
+			report("must have at least %d items", *s.MinItems)
+		}
+		if s.MaxItems != nil && len(v) > *s.MaxItems {
+			report("must have at most %d items", *s.MaxItems)
+		}
+		for i, item := range v {
+			s.Items.validate(path+"/"+strconv.Itoa(i), item, problems)
+		}
+	case map[string]any:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				*problems = append(*problems, ValidationProblem{Path: path + "/" + escapePointer(name), Message: "is required"})
+			}
+		}
+		extra, allowExtra := s.AdditionalProperties.(map[string]any)
+		if allow, ok := s.AdditionalProperties.(bool); ok {
+			allowExtra = allow
+		}
+		for name, item := range v {
+			itemPath := path + "/" + escapePointer(name)
+			if prop, ok := s.Properties[name]; ok {
+				prop.validate(itemPath, item, problems)
+			} else if extra != nil {
+				schemaFromMap(extra).validate(itemPath, item, problems)
+			} else if len(s.Properties) > 0 && !allowExtra {
+				*problems = append(*problems, ValidationProblem{Path: itemPath, Message: "unknown field"})
+			}
+		}
+	}
+}
+
+// schemaFromMap decodes an additionalProperties schema.
+func schemaFromMap(m map[string]any) *Schema {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil
+	}
+	var s Schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil
+	}
+	return &s
+}
+
+func matchesType(schemaType string, value any) bool {
+	switch schemaType {
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		v, ok := value.(float64)
+		return ok && v == math.Trunc(v)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	case "null":
+		return value == nil
+	}
+	return true
+}
+
+func jsonType(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case bool:
+		return "boolean"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func inEnum(enum []any, value any) bool {
+	for _, allowed := range enum {
+		if reflect.DeepEqual(allowed, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// escapePointer escapes a key for use in a JSON pointer (RFC 6901).
+func escapePointer(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSchemaValidate(t *testing.T) {
+	server := newCatalogServer(t, nil)
+	client := NewClient(WithAPIKey("test-key"), WithBaseURL(server.URL))
+	model, err := client.GetModel(context.Background(), "wavespeed-ai/z-image/turbo")
+	if err != nil {
+		t.Fatalf("get model error: %v", err)
+	}
+
+	problems := model.InputSchema.Validate(map[string]any{
+		"promt":          "Cat",
+		"size":           "800*600",
+		"seed":           1.5,
+		"num_images":     8,
+		"guidance_scale": "high",
+		"loras": []map[string]any{
+			{"path": "a", "scale": 5},
+			{"scale": 1},
+			{"path": "c"},
+		},
+	})
+
+	want := []string{
+		"/guidance_scale: expected number, got string",
+		"/loras: must have at most 2 items",
+		"/loras/0/scale: must be at most 4",
+		"/loras/1/path: is required",
+		"/num_images: must be at most 4",
+		"/prompt: is required",
+		"/promt: unknown field",
+		"/seed: expected integer, got number",
+		`/size: must be one of "512*512", "1024*1024"`,
+	}
+	var got []string
+	for _, p := range problems {
+		got = append(got, p.String())
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("problems:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	if problems := model.InputSchema.Validate(map[string]any{"prompt": "Cat", "seed": 42, "size": "512*512"}); len(problems) != 0 {
+		t.Errorf("expected valid input, got %v", problems)
+	}
+}
+
+func TestSchemaValidateAdditionalProperties(t *testing.T) {
+	schema := &Schema{
+		Type:                 "object",
+		Properties:           map[string]*Schema{"a/b": {Type: "string"}},
+		AdditionalProperties: map[string]any{"type": "integer"},
+	}
+	problems := schema.Validate(map[string]any{"a/b": 1, "extra": "x", "other": 2})
+	if len(problems) != 2 || problems[0].Path != "/a~1b" || problems[1].Path != "/extra" {
+		t.Errorf("unexpected problems: %v", problems)
+	}
+
+	schema.AdditionalProperties = true
+	if problems := schema.Validate(map[string]any{"anything": true}); len(problems) != 0 {
+		t.Errorf("expected extra keys to be allowed, got %v", problems)
+	}
+}
+
+func TestRunWithValidation(t *testing.T) {
+	submits := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/models", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(testCatalog))
+	})
+	mux.HandleFunc("/api/v3/wavespeed-ai/z-image/turbo", func(w http.ResponseWriter, r *http.Request) {
+		submits++
+		w.Write([]byte(`{"code":200,"data":{"id":"req-123"}}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := NewClient(WithAPIKey("test-key"), WithBaseURL(server.URL))
+	input := map[string]any{"prompt": "Cat", "sead": 42}
+
+	_, err := client.Run("wavespeed-ai/z-image/turbo", input, WithValidation(), WithMaxRetries(2))
+	var invalid *ValidationError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+	if invalid.Model != "wavespeed-ai/z-image/turbo" || len(invalid.Problems) != 1 || invalid.Problems[0].Path != "/sead" {
+		t.Errorf("unexpected validation error: %+v", invalid)
+	}
+
+	if _, err := client.Submit("wavespeed-ai/z-image/turbo", input, WithValidation()); !errors.As(err, &invalid) {
+		t.Errorf("expected ValidationError from Submit, got %v", err)
+	}
+	result := client.RunNoThrow("wavespeed-ai/z-image/turbo", input, WithValidation())
+	if result.Outputs != nil || !strings.Contains(result.Detail.Error, "/sead: unknown field") {
+		t.Errorf("unexpected RunNoThrow result: %+v", result.Detail)
+	}
+	if submits != 0 {
+		t.Errorf("expected invalid input not to be submitted, got %d submissions", submits)
+	}
+
+	if _, err := client.Submit("wavespeed-ai/z-image/turbo", map[string]any{"prompt": "Cat"}, WithValidation()); err != nil {
+		t.Errorf("submit error: %v", err)
+	}
+	if _, err := client.Submit("acme/unknown", nil, WithValidation()); err == nil || !strings.Contains(err.Error(), "model not found") {
+		t.Errorf("expected model not found error, got %v", err)
+	}
+}
+
+func TestRunWithValidationRespectsContext(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.Write([]byte(testCatalog))
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := NewClient(WithAPIKey("test-key"), WithBaseURL(server.URL))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := client.RunContext(ctx, "wavespeed-ai/z-image/turbo", map[string]any{"prompt": "Cat"}, WithValidation())
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the catalog lookup to stop at the deadline, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected validation to honor the deadline, took %v", elapsed)
+	}
+}
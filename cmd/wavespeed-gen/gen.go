@@ -0,0 +1,339 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/WaveSpeedAI/wavespeed-go/api"
+)
+
+// initialisms are words written in upper case in Go identifiers.
+var initialisms = map[string]bool{
+	"api": true, "http": true, "id": true, "json": true, "uri": true, "url": true,
+}
+
+// generate returns the formatted Go source for models in package pkg.
+func generate(pkg string, models []api.Model) ([]byte, error) {
+	g := &generator{declared: make(map[string]bool)}
+
+	sorted := append([]api.Model(nil), models...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+	for _, m := range sorted {
+		g.model(m)
+	}
+
+	var src bytes.Buffer
+	src.WriteString("// Code generated by wavespeed-gen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&src, "package %s\n\n", pkg)
+	src.WriteString("import \"github.com/WaveSpeedAI/wavespeed-go/api\"\n")
+	src.Write(g.buf.Bytes())
+
+	formatted, err := format.Source(src.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format generated code: %w", err)
+	}
+	return formatted, nil
+}
+
+type generator struct {
+	buf      bytes.Buffer
+	declared map[string]bool
+}
+
+func (g *generator) printf(format string, args ...any) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// name returns a type name not declared yet, based on want.
+func (g *generator) name(want string) string {
+	name := want
+	for i := 2; g.declared[name]; i++ {
+		name = want + strconv.Itoa(i)
+	}
+	g.declared[name] = true
+	return name
+}
+
+// prefix returns a type name prefix for a model based on want, and declares
+// the model constant and run function named after it.
+func (g *generator) prefix(want string) string {
+	prefix := want
+	for i := 2; g.declared[prefix] || g.declared[prefix+"Model"] || g.declared["Run"+prefix]; i++ {
+		prefix = want + strconv.Itoa(i)
+	}
+	for _, name := range []string{prefix, prefix + "Model", "Run" + prefix} {
+		g.declared[name] = true
+	}
+	return prefix
+}
+
+func (g *generator) model(m api.Model) {
+	prefix := g.prefix(modelTypeName(m.ID))
+	title := m.Name
+	if title == "" {
+		title = m.ID
+	}
+
+	g.printf("\n// %sModel is the model ID of %s.\nconst %sModel = %q\n", prefix, title, prefix, m.ID)
+
+	inputSchema := m.InputSchema
+	if inputSchema == nil {
+		inputSchema = &api.Schema{Type: "object"}
+	}
+	input := g.name(prefix + "Input")
+	doc := []string{fmt.Sprintf("%s is the input of %s.", input, m.ID)}
+	if m.Description != "" {
+		doc = append(doc, "", oneLine(m.Description))
+	}
+	g.structType(input, prefix, doc, inputSchema)
+
+	output := g.name(prefix + "Output")
+	g.outputType(output, m.ID, m.OutputSchema)
+
+	g.printf("\n// Run%s runs %s and waits for its outputs.\n", prefix, m.ID)
+	g.printf("func Run%s(client api.Runner, input %s, opts ...api.RunOption) (%s, error) {\n", prefix, input, output)
+	g.printf("\treturn api.RunTyped[%s, %s](client, %sModel, input, opts...)\n}\n", input, output, prefix)
+}
+
+// structType declares a struct for an object schema. Types for its fields are
+// named after typePrefix and the field name.
+func (g *generator) structType(name, typePrefix string, doc []string, schema *api.Schema) {
+	required := make(map[string]bool)
+	for _, prop := range schema.Required {
+		required[prop] = true
+	}
+	props := make([]string, 0, len(schema.Properties))
+	for prop := range schema.Properties {
+		props = append(props, prop)
+	}
+	sort.Strings(props)
+
+	// Field types are resolved first so that nested declarations follow the struct.
+	var fields bytes.Buffer
+	var nested []func()
+	fieldNames := make(map[string]bool)
+	for _, prop := range props {
+		ps := schema.Properties[prop]
+		base := identifier(prop)
+		if base == "" {
+			// Names such as "_" have no letters or digits to keep.
+			base = "Field"
+		}
+		field := base
+		for i := 2; fieldNames[field]; i++ {
+			field = base + strconv.Itoa(i)
+		}
+		fieldNames[field] = true
+
+		typ, decl := g.fieldType(typePrefix+field, name+"."+field, ps)
+		if decl != nil {
+			nested = append(nested, decl)
+		}
+		tag := prop
+		if !required[prop] {
+			// encoding/json never omits a struct, so optional objects are pointers too.
+			if isScalar(typ) || isEnum(ps) || isStruct(ps) {
+				typ = "*" + typ
+			}
+			tag += ",omitempty"
+		}
+
+		if comment := fieldDoc(ps, required[prop]); comment != "" {
+			fmt.Fprintf(&fields, "\t// %s\n", comment)
+		}
+		fmt.Fprintf(&fields, "\t%s %s `json:%q`\n", field, typ, tag)
+	}
+
+	g.printf("\n")
+	for _, line := range doc {
+		g.printf("//%s\n", prefixSpace(line))
+	}
+	g.printf("type %s struct {\n%s}\n", name, fields.String())
+	for _, decl := range nested {
+		decl()
+	}
+}
+
+// fieldType returns the Go type for schema, used by field, and a function
+// that declares any named type it needs, to be called after the enclosing
+// declaration.
+func (g *generator) fieldType(name, field string, schema *api.Schema) (string, func()) {
+	if isEnum(schema) {
+		typeName := g.name(name)
+		return typeName, func() { g.enumType(typeName, field, schema) }
+	}
+
+	switch schema.Type {
+	case "string":
+		return "string", nil
+	case "integer":
+		return "int", nil
+	case "number":
+		return "float64", nil
+	case "boolean":
+		return "bool", nil
+	case "array":
+		if schema.Items == nil {
+			return "[]any", nil
+		}
+		typ, decl := g.fieldType(name+"Item", field, schema.Items)
+		return "[]" + typ, decl
+	case "object":
+		if len(schema.Properties) == 0 {
+			return "map[string]any", nil
+		}
+		typeName := g.name(name)
+		return typeName, func() {
+			g.structType(typeName, typeName, []string{fmt.Sprintf("%s is the type of %s.", typeName, field)}, schema)
+		}
+	}
+	return "any", nil
+}
+
+// enumType declares a named type with a constant for each allowed value.
+func (g *generator) enumType(name, field string, schema *api.Schema) {
+	base := "string"
+	if schema.Type == "integer" {
+		base = "int"
+	} else if schema.Type == "number" {
+		base = "float64"
+	}
+
+	g.printf("\n// %s is an allowed value of %s.\ntype %s %s\n\n", name, field, name, base)
+	g.printf("const (\n")
+	used := make(map[string]bool)
+	for i, value := range schema.Enum {
+		suffix := camelCase(fmt.Sprint(value))
+		if suffix == "" || used[suffix] {
+			suffix = "Value" + strconv.Itoa(i)
+		}
+		used[suffix] = true
+		literal, _ := json.Marshal(value)
+		g.printf("\t%s %s = %s\n", g.name(name+suffix), name, literal)
+	}
+	g.printf(")\n")
+}
+
+// outputType declares the decoded outputs of a model and their accessors.
+func (g *generator) outputType(name, modelID string, schema *api.Schema) {
+	g.printf("\n// %s is the outputs of %s.\n", name, modelID)
+
+	switch {
+	case schema != nil && schema.Type == "array" && schema.Items != nil && schema.Items.Type == "string":
+		g.printf("type %s []string\n", name)
+		g.printf("\n// First returns the first output, or \"\" if there is none.\n")
+		g.printf("func (o %s) First() string {\n\tif len(o) == 0 {\n\t\treturn \"\"\n\t}\n\treturn o[0]\n}\n", name)
+	case schema != nil && schema.Type == "array" && schema.Items != nil && schema.Items.Type == "object" && len(schema.Items.Properties) > 0:
+		item := g.name(name + "Item")
+		g.printf("type %s []%s\n", name, item)
+		g.structType(item, item, []string{fmt.Sprintf("%s is a single output of %s.", item, modelID)}, schema.Items)
+	default:
+		g.printf("type %s []any\n", name)
+	}
+}
+
+func fieldDoc(schema *api.Schema, required bool) string {
+	var parts []string
+	if schema.Description != "" {
+		desc := oneLine(schema.Description)
+		if !strings.HasSuffix(desc, ".") {
+			desc += "."
+		}
+		parts = append(parts, desc)
+	}
+	if required {
+		parts = append(parts, "Required.")
+	}
+	if schema.Default != nil {
+		literal, _ := json.Marshal(schema.Default)
+		parts = append(parts, fmt.Sprintf("Default: %s.", literal))
+	}
+	if schema.Minimum != nil && schema.Maximum != nil {
+		parts = append(parts, fmt.Sprintf("Range: %s to %s.", formatNumber(*schema.Minimum), formatNumber(*schema.Maximum)))
+	} else if schema.Minimum != nil {
+		parts = append(parts, fmt.Sprintf("Minimum: %s.", formatNumber(*schema.Minimum)))
+	} else if schema.Maximum != nil {
+		parts = append(parts, fmt.Sprintf("Maximum: %s.", formatNumber(*schema.Maximum)))
+	}
+	return strings.Join(parts, " ")
+}
+
+func isEnum(schema *api.Schema) bool {
+	if len(schema.Enum) == 0 {
+		return false
+	}
+	return schema.Type == "string" || schema.Type == "integer" || schema.Type == "number"
+}
+
+// isStruct reports whether schema is generated as a struct type.
+func isStruct(schema *api.Schema) bool {
+	return !isEnum(schema) && schema.Type == "object" && len(schema.Properties) > 0
+}
+
+func isScalar(typ string) bool {
+	return typ == "string" || typ == "int" || typ == "float64" || typ == "bool"
+}
+
+// modelTypeName derives a type name prefix from a model ID, dropping the
+// owner: "wavespeed-ai/z-image/turbo" becomes "ZImageTurbo".
+func modelTypeName(id string) string {
+	if _, rest, ok := strings.Cut(id, "/"); ok {
+		id = rest
+	}
+	name := identifier(id)
+	if name == "" {
+		return "Model"
+	}
+	return name
+}
+
+// identifier converts s to an exported Go identifier: "num_images" becomes
+// "NumImages" and "512*512" becomes "X512x512".
+func identifier(s string) string {
+	name := camelCase(s)
+	if name != "" && unicode.IsDigit([]rune(name)[0]) {
+		name = "X" + name
+	}
+	return name
+}
+
+// camelCase joins the words of s in camel case, e.g. "512*512" becomes "512x512".
+func camelCase(s string) string {
+	s = strings.ReplaceAll(s, "*", "x")
+	words := strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var b strings.Builder
+	for _, word := range words {
+		if initialisms[strings.ToLower(word)] {
+			b.WriteString(strings.ToUpper(word))
+			continue
+		}
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		b.WriteString(string(runes))
+	}
+	return b.String()
+}
+
+func formatNumber(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func prefixSpace(line string) string {
+	if line == "" {
+		return ""
+	}
+	return " " + line
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/WaveSpeedAI/wavespeed-go/api"
+)
+
+func TestGenerate(t *testing.T) {
+	models, err := loadModels(filepath.Join("testdata", "catalog.json"), "")
+	if err != nil {
+		t.Fatalf("failed to load catalog: %v", err)
+	}
+	selected, err := selectModels(models, []string{"wavespeed-ai/z-image/turbo"}, "")
+	if err != nil {
+		t.Fatalf("failed to select models: %v", err)
+	}
+
+	src, err := generate("models", selected)
+	if err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), "models_gen.go", src, 0); err != nil {
+		t.Fatalf("generated code does not parse: %v\n%s", err, src)
+	}
+
+	out := string(src)
+	for _, want := range []string{
+		"// Code generated by wavespeed-gen. DO NOT EDIT.",
+		"package models",
+		`const ZImageTurboModel = "wavespeed-ai/z-image/turbo"`,
+		"type ZImageTurboInput struct",
+		"Prompt string `json:\"prompt\"`",
+		"Seed *int `json:\"seed,omitempty\"`",
+		"Range: -1 to 2147483647.",
+		`ZImageTurboSize512x512   ZImageTurboSize = "512*512"`,
+		"type ZImageTurboOutput []string",
+		"func (o ZImageTurboOutput) First() string",
+		"func RunZImageTurbo(client api.Runner, input ZImageTurboInput, opts ...api.RunOption) (ZImageTurboOutput, error)",
+		"Model *ZImageTurboModel2 `json:\"model,omitempty\"`",
+		"type ZImageTurboModel2 struct",
+		"Field *string `json:\"_,omitempty\"`",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected generated code to contain %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestGenerateUniqueNames(t *testing.T) {
+	models, err := loadModels(filepath.Join("testdata", "catalog.json"), "")
+	if err != nil {
+		t.Fatalf("failed to load catalog: %v", err)
+	}
+	// The enum constant FooSizeA and the type of size_a want the same name,
+	// as do the model constant FooModel and the type of model.
+	models = append(models, api.Model{ID: "acme/foo", InputSchema: &api.Schema{Type: "object", Properties: map[string]*api.Schema{
+		"size":   {Type: "string", Enum: []any{"a"}},
+		"size_a": {Type: "object", Properties: map[string]*api.Schema{"x": {Type: "integer"}}},
+		"model":  {Type: "object", Properties: map[string]*api.Schema{"y": {Type: "integer"}}},
+	}}})
+
+	src, err := generate("models", models)
+	if err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+	file, err := parser.ParseFile(token.NewFileSet(), "models_gen.go", src, 0)
+	if err != nil {
+		t.Fatalf("generated code does not parse: %v\n%s", err, src)
+	}
+
+	declared := make(map[string]bool)
+	for _, decl := range file.Decls {
+		var names []string
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if decl.Recv == nil {
+				names = append(names, decl.Name.Name)
+			}
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					names = append(names, spec.Name.Name)
+				case *ast.ValueSpec:
+					for _, name := range spec.Names {
+						names = append(names, name.Name)
+					}
+				}
+			}
+		}
+		for _, name := range names {
+			if declared[name] {
+				t.Errorf("%s is declared twice:\n%s", name, src)
+			}
+			declared[name] = true
+		}
+	}
+	if !declared["FooModel"] || !declared["FooSizeA"] || !declared["RunFoo"] {
+		t.Errorf("expected the model constant, enum constant and run function to keep their names:\n%s", src)
+	}
+}
+
+func TestSelectModels(t *testing.T) {
+	models, err := loadModels(filepath.Join("testdata", "catalog.json"), "")
+	if err != nil {
+		t.Fatalf("failed to load catalog: %v", err)
+	}
+
+	if _, err := selectModels(models, []string{"missing/model"}, ""); err == nil || !strings.Contains(err.Error(), "model not found: missing/model") {
+		t.Errorf("expected model not found error, got %v", err)
+	}
+	if _, err := selectModels(models, nil, "no-such-category"); err == nil {
+		t.Error("expected error for empty selection")
+	}
+
+	selected, err := selectModels(models, nil, "text-to-image")
+	if err != nil {
+		t.Fatalf("failed to select models: %v", err)
+	}
+	for _, m := range selected {
+		if m.Category != "text-to-image" {
+			t.Errorf("unexpected model %s in category %s", m.ID, m.Category)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "models_gen.go")
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"-catalog", filepath.Join("testdata", "catalog.json"), "-package", "gen", "-o", output}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+	if !strings.Contains(string(data), "package gen") {
+		t.Errorf("unexpected output:\n%s", data)
+	}
+
+	stderr.Reset()
+	code = run([]string{"-catalog", filepath.Join("testdata", "catalog.json"), "missing/model"}, &stdout, &stderr)
+	if code != 1 || !strings.Contains(stderr.String(), "model not found") {
+		t.Errorf("expected failure for unknown model, got %d: %s", code, stderr.String())
+	}
+}
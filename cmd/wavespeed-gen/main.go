@@ -0,0 +1,145 @@
+// Command wavespeed-gen generates typed Go input and output structs for
+// WaveSpeed models from their catalog schemas.
+//
+// Usage:
+//
+//	wavespeed-gen [flags] [model...]
+//
+// For every model it writes a <Name>Model constant, a <Name>Input struct
+// with typed constants for enum fields, a <Name>Output type and a
+// Run<Name> function built on api.RunTyped. Without model arguments every
+// model in the catalog, optionally limited with -category, is generated.
+//
+// The catalog is fetched from the API with the settings of the config file
+// profile selected with -profile and the WAVESPEED_* environment variables,
+// or read from a JSON file with -catalog for reproducible builds.
+//
+// Example:
+//
+//	//go:generate go run github.com/WaveSpeedAI/wavespeed-go/cmd/wavespeed-gen -o models_gen.go wavespeed-ai/z-image/turbo
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/WaveSpeedAI/wavespeed-go/api"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("wavespeed-gen", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: wavespeed-gen [flags] [model...]")
+		fs.PrintDefaults()
+	}
+
+	defaultPackage := os.Getenv("GOPACKAGE")
+	if defaultPackage == "" {
+		defaultPackage = "models"
+	}
+	var (
+		output   string
+		pkg      string
+		catalog  string
+		category string
+		profile  string
+	)
+	fs.StringVar(&output, "o", "", "output file (default stdout)")
+	fs.StringVar(&pkg, "package", defaultPackage, "package name of the generated file")
+	fs.StringVar(&catalog, "catalog", "", "read models from this JSON file instead of the API")
+	fs.StringVar(&category, "category", "", "only generate models in this category")
+	fs.StringVar(&profile, "profile", "", "config file profile to use")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	models, err := loadModels(catalog, profile)
+	if err != nil {
+		fmt.Fprintf(stderr, "wavespeed-gen: %v\n", err)
+		return 1
+	}
+	selected, err := selectModels(models, fs.Args(), category)
+	if err != nil {
+		fmt.Fprintf(stderr, "wavespeed-gen: %v\n", err)
+		return 1
+	}
+
+	src, err := generate(pkg, selected)
+	if err != nil {
+		fmt.Fprintf(stderr, "wavespeed-gen: %v\n", err)
+		return 1
+	}
+
+	if output == "" {
+		stdout.Write(src)
+		return 0
+	}
+	if err := os.WriteFile(output, src, 0644); err != nil {
+		fmt.Fprintf(stderr, "wavespeed-gen: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// loadModels reads the catalog from path, which may hold either a list of
+// models or a full API response, or fetches it from the API.
+func loadModels(path, profile string) ([]api.Model, error) {
+	if path == "" {
+		client := api.NewClient(api.WithProfile(profile))
+		return client.ListModels(context.Background())
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read catalog: %w", err)
+	}
+	var models []api.Model
+	if err := json.Unmarshal(data, &models); err == nil {
+		return models, nil
+	}
+	var response struct {
+		Data []api.Model `json:"data"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse catalog: %w", err)
+	}
+	return response.Data, nil
+}
+
+func selectModels(models []api.Model, ids []string, category string) ([]api.Model, error) {
+	if len(ids) == 0 {
+		var selected []api.Model
+		for _, m := range models {
+			if category == "" || m.Category == category {
+				selected = append(selected, m)
+			}
+		}
+		if len(selected) == 0 {
+			return nil, fmt.Errorf("no models to generate")
+		}
+		return selected, nil
+	}
+
+	byID := make(map[string]api.Model, len(models))
+	for _, m := range models {
+		byID[m.ID] = m
+	}
+	selected := make([]api.Model, 0, len(ids))
+	for _, id := range ids {
+		m, ok := byID[id]
+		if !ok {
+			return nil, fmt.Errorf("model not found: %s", id)
+		}
+		selected = append(selected, m)
+	}
+	return selected, nil
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/WaveSpeedAI/wavespeed-go/api"
+)
+
+func balanceCommand(env *cliEnv, args []string) int {
+	fs := env.newFlagSet("balance", "[flags]")
+	var (
+		client clientFlags
+		below  float64
+		asJSON bool
+	)
+	client.register(fs)
+	fs.Float64Var(&below, "below", 0, "exit with status 1 if the balance is below this amount")
+	fs.BoolVar(&asJSON, "json", false, "print the balance as JSON")
+	if err := parseArgs(fs, args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return 2
+	}
+
+	balance, err := client.newClient().GetBalance(context.Background())
+	if err != nil {
+		return env.fail("%v", err)
+	}
+	if asJSON {
+		if code := env.writeJSONOrFail(balance); code != 0 {
+			return code
+		}
+	} else {
+		fmt.Fprintf(env.stdout, "Balance: %s\n", formatAmount(balance.Balance, balance.Currency))
+	}
+	if balance.Balance < below {
+		return env.fail("balance %s is below %g", formatAmount(balance.Balance, balance.Currency), below)
+	}
+	return 0
+}
+
+func usageCommand(env *cliEnv, args []string) int {
+	fs := env.newFlagSet("usage", "[flags]")
+	var (
+		client   clientFlags
+		from, to string
+		by       string
+		asJSON   bool
+	)
+	client.register(fs)
+	fs.StringVar(&from, "from", "", "start of the range, YYYY-MM-DD or RFC 3339 (default: start of this month)")
+	fs.StringVar(&to, "to", "", "end of the range, exclusive, YYYY-MM-DD or RFC 3339 (default: now)")
+	fs.StringVar(&by, "by", "", "break down spend by model or day")
+	fs.BoolVar(&asJSON, "json", false, "print the usage as JSON")
+	if err := parseArgs(fs, args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return 2
+	}
+
+	now := time.Now().UTC()
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	end := now
+	var err error
+	if from != "" {
+		if start, err = parseDate(from); err != nil {
+			return env.fail("invalid --from: %v", err)
+		}
+	}
+	if to != "" {
+		if end, err = parseDate(to); err != nil {
+			return env.fail("invalid --to: %v", err)
+		}
+	}
+
+	usage, err := client.newClient().GetUsage(context.Background(), start, end, api.UsageGroup(by))
+	if err != nil {
+		return env.fail("%v", err)
+	}
+	if asJSON {
+		return env.writeJSONOrFail(usage)
+	}
+
+	fmt.Fprintf(env.stdout, "Usage from %s to %s\n\n", usage.From.Format(time.RFC3339), usage.To.Format(time.RFC3339))
+	tw := tabwriter.NewWriter(env.stdout, 0, 4, 2, ' ', 0)
+	if len(usage.Items) > 0 {
+		fmt.Fprintf(tw, "%s\tREQUESTS\tCOST\n", usageHeader(usage.GroupBy))
+		for _, item := range usage.Items {
+			fmt.Fprintf(tw, "%s\t%d\t%s\n", item.Key, item.Requests, formatAmount(item.Cost, usage.Currency))
+		}
+	} else {
+		fmt.Fprintln(tw, "\tREQUESTS\tCOST")
+	}
+	fmt.Fprintf(tw, "TOTAL\t%d\t%s\n", usage.TotalRequests, formatAmount(usage.TotalCost, usage.Currency))
+	tw.Flush()
+	return 0
+}
+
+func usageHeader(group api.UsageGroup) string {
+	if group == api.UsageByDay {
+		return "DAY"
+	}
+	return "MODEL"
+}
+
+// parseDate parses a date (as midnight UTC) or an RFC 3339 timestamp.
+func parseDate(s string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+func formatAmount(amount float64, currency string) string {
+	if currency == "" {
+		return fmt.Sprintf("%.4f", amount)
+	}
+	return fmt.Sprintf("%.4f %s", amount, currency)
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestBalanceCommand(t *testing.T) {
+	server := newTestServer(t, map[string]http.HandlerFunc{
+		"/api/v3/balance": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"code":200,"data":{"balance":12.5,"currency":"USD"}}`))
+		},
+	})
+
+	env, stdout, stderr := newTestEnv("")
+	code := env.main([]string{"balance", "--api-key", "test-key", "--base-url", server.URL})
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	if out := stdout.String(); out != "Balance: 12.5000 USD\n" {
+		t.Errorf("unexpected output: %q", out)
+	}
+
+	env, _, stderr = newTestEnv("")
+	code = env.main([]string{"balance", "--api-key", "test-key", "--base-url", server.URL, "--below", "20"})
+	if code != 1 || !strings.Contains(stderr.String(), "below 20") {
+		t.Errorf("expected low balance failure, got %d: %s", code, stderr.String())
+	}
+}
+
+func TestUsageCommand(t *testing.T) {
+	var query string
+	server := newTestServer(t, map[string]http.HandlerFunc{
+		"/api/v3/usage": func(w http.ResponseWriter, r *http.Request) {
+			query = r.URL.RawQuery
+			w.Write([]byte(`{"code":200,"data":{"currency":"USD","total_cost":1.25,"total_requests":30,"items":[
+				{"key":"wavespeed-ai/z-image/turbo","requests":25,"cost":0.125},
+				{"key":"wavespeed-ai/wan-2.1/t2v-480p","requests":5,"cost":1.125}
+			]}}`))
+		},
+	})
+
+	env, stdout, stderr := newTestEnv("")
+	code := env.main([]string{"usage", "--api-key", "test-key", "--base-url", server.URL,
+		"--by", "model", "--from", "2024-05-01", "--to", "2024-06-01"})
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(query, "group_by=model") || !strings.Contains(query, "start_time=2024-05-01T00%3A00%3A00Z") {
+		t.Errorf("unexpected query: %s", query)
+	}
+	out := stdout.String()
+	for _, want := range []string{"2024-05-01T00:00:00Z to 2024-06-01T00:00:00Z", "MODEL", "0.1250 USD", "TOTAL", "1.2500 USD"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output: %q", want, out)
+		}
+	}
+
+	env, _, stderr = newTestEnv("")
+	code = env.main([]string{"usage", "--api-key", "test-key", "--base-url", server.URL, "--from", "May 1"})
+	if code != 1 || !strings.Contains(stderr.String(), "invalid --from") {
+		t.Errorf("expected invalid date failure, got %d: %s", code, stderr.String())
+	}
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/WaveSpeedAI/wavespeed-go/api"
+)
+
+// batchResult is one line of the batch output file.
+type batchResult struct {
+	Line    int    `json:"line"`
+	TaskID  string `json:"taskId,omitempty"`
+	Status  string `json:"status"`
+	Outputs []any  `json:"outputs"`
+	Error   string `json:"error,omitempty"`
+}
+
+type batchJob struct {
+	line  int
+	input map[string]any
+	err   error
+}
+
+func batchCommand(env *cliEnv, args []string) int {
+	fs := env.newFlagSet("batch", "--model <model> --in <inputs.jsonl> --out <results.jsonl> [flags]")
+	var (
+		client      clientFlags
+		run         runFlags
+		model       string
+		in          string
+		out         string
+		concurrency int
+		rate        float64
+	)
+	client.register(fs)
+	run.register(fs)
+	fs.StringVar(&model, "model", "", "model to run for every input line")
+	fs.StringVar(&in, "in", "", "JSONL file with one input object per line, or - for stdin")
+	fs.StringVar(&out, "out", "", "JSONL file to append results to")
+	fs.IntVar(&concurrency, "concurrency", 4, "number of tasks to run in parallel")
+	fs.Float64Var(&rate, "rate", 0, "maximum submissions per second (0 for unlimited)")
+	if err := parseArgs(fs, args); err != nil {
+		return 2
+	}
+	if model == "" || in == "" || out == "" || fs.NArg() != 0 {
+		fs.Usage()
+		return 2
+	}
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	done, err := completedLines(out)
+	if err != nil {
+		return env.fail("%v", err)
+	}
+
+	var r io.Reader = env.stdin
+	if in != "-" {
+		f, err := os.Open(in)
+		if err != nil {
+			return env.fail("%v", err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	outFile, err := os.OpenFile(out, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return env.fail("%v", err)
+	}
+	defer outFile.Close()
+	if err := terminateLastLine(out, outFile); err != nil {
+		return env.fail("%v", err)
+	}
+
+	runner := client.newClient()
+	opts := run.options()
+
+	jobs := make(chan batchJob)
+	results := make(chan batchResult)
+
+	var limiter <-chan time.Time
+	if rate > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / rate))
+		defer ticker.Stop()
+		limiter = ticker.C
+	}
+
+	var workers sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for job := range jobs {
+				if job.err != nil {
+					results <- batchResult{Line: job.line, Status: "failed", Error: job.err.Error()}
+					continue
+				}
+				if limiter != nil {
+					<-limiter
+				}
+				results <- runBatchJob(runner, model, job, opts)
+			}
+		}()
+	}
+
+	var readErr error
+	skipped := 0
+	go func() {
+		defer close(jobs)
+		skipped, readErr = readBatchJobs(r, done, jobs)
+	}()
+
+	go func() {
+		workers.Wait()
+		close(results)
+	}()
+
+	enc := json.NewEncoder(outFile)
+	processed, failed := 0, 0
+	var writeErr error
+	for result := range results {
+		processed++
+		if result.Error != "" {
+			failed++
+		}
+		if writeErr == nil {
+			writeErr = enc.Encode(result)
+		}
+		fmt.Fprintf(env.stderr, "[%d] line %d %s %s\n", processed, result.Line, result.Status, result.TaskID)
+	}
+
+	fmt.Fprintf(env.stderr, "Processed %d, failed %d, skipped %d already in %s\n", processed, failed, skipped, out)
+	if writeErr != nil {
+		return env.fail("%v", writeErr)
+	}
+	if readErr != nil {
+		return env.fail("%v", readErr)
+	}
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+func runBatchJob(runner api.Runner, model string, job batchJob, opts []api.RunOption) batchResult {
+	result := runner.RunNoThrow(model, job.input, opts...)
+	line := batchResult{
+		Line:    job.line,
+		TaskID:  result.Detail.TaskID,
+		Status:  result.Detail.Status,
+		Outputs: result.Outputs,
+		Error:   result.Detail.Error,
+	}
+	if line.TaskID == "unknown" {
+		line.TaskID = ""
+	}
+	return line
+}
+
+// readBatchJobs streams input lines into jobs, skipping blank lines and line
+// numbers already present in the output file. Line numbers start at 1.
+func readBatchJobs(r io.Reader, done map[int]bool, jobs chan<- batchJob) (int, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	skipped := 0
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		if done[line] {
+			skipped++
+			continue
+		}
+
+		job := batchJob{line: line}
+		if err := json.Unmarshal([]byte(text), &job.input); err != nil {
+			job.err = fmt.Errorf("invalid input JSON: %w", err)
+		}
+		jobs <- job
+	}
+	return skipped, scanner.Err()
+}
+
+// completedLines returns the input line numbers already recorded in the
+// output file, so an interrupted batch can be resumed.
+func completedLines(file string) (map[int]bool, error) {
+	done := map[int]bool{}
+
+	f, err := os.Open(file)
+	if os.IsNotExist(err) {
+		return done, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var result batchResult
+		// A partially written last line from a crash is ignored and rerun.
+		if err := json.Unmarshal(scanner.Bytes(), &result); err == nil && result.Line > 0 {
+			done[result.Line] = true
+		}
+	}
+	return done, scanner.Err()
+}
+
+// terminateLastLine appends a newline when a previous run was interrupted in
+// the middle of writing a result, so new results start on their own line.
+func terminateLastLine(name string, f *os.File) error {
+	data, err := os.ReadFile(name)
+	if err != nil || len(data) == 0 || data[len(data)-1] == '\n' {
+		return err
+	}
+	_, err = f.Write([]byte("\n"))
+	return err
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestBatchCommandResumes(t *testing.T) {
+	var mu sync.Mutex
+	var prompts []string
+	server := newTestServer(t, map[string]http.HandlerFunc{
+		"/api/v3/wavespeed-ai/z-image/turbo": func(w http.ResponseWriter, r *http.Request) {
+			var body map[string]any
+			json.NewDecoder(r.Body).Decode(&body)
+			prompt, _ := body["prompt"].(string)
+			mu.Lock()
+			prompts = append(prompts, prompt)
+			mu.Unlock()
+			if prompt == "fail" {
+				w.Write([]byte(`{"code":200,"data":{"id":"req-fail","status":"failed","error":"Model error"}}`))
+				return
+			}
+			w.Write([]byte(`{"code":200,"data":{"id":"req-` + prompt + `","status":"completed","outputs":["https://example.com/` + prompt + `.png"]}}`))
+		},
+	})
+
+	dir := t.TempDir()
+	in := filepath.Join(dir, "prompts.jsonl")
+	out := filepath.Join(dir, "results.jsonl")
+	inputs := `{"prompt":"cat"}
+{"prompt":"dog"}
+
+{"prompt":"fail"}
+not json
+`
+	if err := os.WriteFile(in, []byte(inputs), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// Line 2 was finished by a previous run; the last line was cut off mid-write.
+	if err := os.WriteFile(out, []byte(`{"line":2,"taskId":"req-dog","status":"completed","outputs":[]}`+"\n"+`{"line":1,"ta`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	env, _, stderr := newTestEnv("")
+	code := env.main([]string{"batch", "--api-key", "test-key", "--base-url", server.URL, "--sync",
+		"--model", "wavespeed-ai/z-image/turbo", "--in", in, "--out", out, "--concurrency", "2", "--rate", "100"})
+	if code != 1 {
+		t.Fatalf("expected exit code 1 for failed lines, got %d: %s", code, stderr.String())
+	}
+
+	sort.Strings(prompts)
+	if strings.Join(prompts, ",") != "cat,fail" {
+		t.Errorf("expected only unfinished lines to run, got %v", prompts)
+	}
+
+	f, err := os.Open(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	results := map[int]batchResult{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var result batchResult
+		if json.Unmarshal(scanner.Bytes(), &result) == nil {
+			results[result.Line] = result
+		}
+	}
+
+	if len(results) != 4 {
+		t.Fatalf("expected results for lines 1, 2, 4 and 5, got %+v", results)
+	}
+	if r := results[1]; r.TaskID != "req-cat" || len(r.Outputs) != 1 || r.Error != "" {
+		t.Errorf("unexpected result for line 1: %+v", r)
+	}
+	if r := results[4]; r.TaskID != "req-fail" || !strings.Contains(r.Error, "Model error") {
+		t.Errorf("unexpected result for line 4: %+v", r)
+	}
+	if r := results[5]; !strings.Contains(r.Error, "invalid input JSON") {
+		t.Errorf("unexpected result for line 5: %+v", r)
+	}
+}
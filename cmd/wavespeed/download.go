@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// downloadClient fetches output files. The timeout covers the whole transfer,
+// so it leaves room for large videos.
+var downloadClient = &http.Client{Timeout: 10 * time.Minute}
+
+// downloadOutputs saves every URL output into dir and returns the local paths.
+// Non-URL outputs (e.g. text from LLM models) are skipped.
+func downloadOutputs(dir string, outputs []any) ([]string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	var files []string
+	used := map[string]bool{}
+	for i, output := range outputs {
+		rawURL, ok := output.(string)
+		if !ok || !isURL(rawURL) {
+			continue
+		}
+
+		name := outputFileName(rawURL, i)
+		if used[name] {
+			name = fmt.Sprintf("%d-%s", i, name)
+		}
+		used[name] = true
+
+		file := filepath.Join(dir, name)
+		if err := downloadFile(rawURL, file); err != nil {
+			return files, err
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+func isURL(s string) bool {
+	return strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://")
+}
+
+func outputFileName(rawURL string, index int) string {
+	if u, err := url.Parse(rawURL); err == nil {
+		if base := path.Base(u.Path); base != "" && base != "/" && base != "." {
+			return base
+		}
+	}
+	return fmt.Sprintf("output-%d", index)
+}
+
+// downloadFile saves rawURL as file. The data goes to a temporary file that
+// replaces file only once the download is complete, so a failed download
+// never leaves a truncated file behind.
+func downloadFile(rawURL, file string) error {
+	resp, err := downloadClient.Get(rawURL)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", rawURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("failed to download %s: HTTP %d", rawURL, resp.StatusCode)
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(file), "."+filepath.Base(file)+".*.part")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("failed to download %s: %w", rawURL, err)
+	}
+	// Temporary files are private; downloads get the usual permissions.
+	if err := f.Chmod(0o644); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, file); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/WaveSpeedAI/wavespeed-go/api"
+)
+
+func uploadCommand(env *cliEnv, args []string) int {
+	flags := env.newFlagSet("upload", "[flags] <file|dir|glob>...")
+	var (
+		client      clientFlags
+		concurrency int
+		timeout     float64
+		asJSON      bool
+	)
+	client.register(flags)
+	flags.IntVar(&concurrency, "concurrency", 4, "number of files to upload in parallel")
+	flags.Float64Var(&timeout, "timeout", 36000, "upload timeout per file in seconds")
+	flags.BoolVar(&asJSON, "json", false, "print the path to URL mapping as JSON")
+	if err := parseArgs(flags, args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	files, err := expandPaths(flags.Args())
+	if err != nil {
+		return env.fail("%v", err)
+	}
+	if len(files) == 0 {
+		return env.fail("no files match %s", strings.Join(flags.Args(), " "))
+	}
+
+	urls, errs := uploadFiles(client.newClient(), files, concurrency, api.WithUploadTimeout(timeout))
+	for _, file := range files {
+		if err, ok := errs[file]; ok {
+			fmt.Fprintf(env.stderr, "wavespeed: %s: %v\n", file, err)
+		}
+	}
+
+	if asJSON {
+		if code := env.writeJSONOrFail(urls); code != 0 {
+			return code
+		}
+	} else {
+		tw := tabwriter.NewWriter(env.stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "PATH\tURL")
+		for _, file := range files {
+			if url, ok := urls[file]; ok {
+				fmt.Fprintf(tw, "%s\t%s\n", file, url)
+			}
+		}
+		tw.Flush()
+	}
+
+	if len(errs) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// expandPaths resolves glob patterns and walks directories, returning a
+// sorted, de-duplicated list of regular files.
+func expandPaths(patterns []string) ([]string, error) {
+	seen := map[string]bool{}
+	var files []string
+	add := func(path string) {
+		if !seen[path] {
+			seen[path] = true
+			files = append(files, path)
+		}
+	}
+
+	for _, pattern := range patterns {
+		matches := []string{pattern}
+		if strings.ContainsAny(pattern, "*?[") {
+			var err error
+			if matches, err = filepath.Glob(pattern); err != nil {
+				return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+			}
+		}
+
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil {
+				return nil, err
+			}
+			if !info.IsDir() {
+				add(match)
+				continue
+			}
+			err = filepath.WalkDir(match, func(path string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				if d.Type().IsRegular() && !strings.HasPrefix(d.Name(), ".") {
+					add(path)
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// uploadFiles uploads files with at most concurrency uploads in flight.
+func uploadFiles(runner api.Runner, files []string, concurrency int, opts ...api.UploadOption) (map[string]string, map[string]error) {
+	urls := map[string]string{}
+	errs := map[string]error{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+
+	for _, file := range files {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(file string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			url, err := runner.Upload(file, opts...)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs[file] = err
+				return
+			}
+			urls[file] = url
+		}(file)
+	}
+
+	wg.Wait()
+	return urls, errs
+}
+
+func downloadCommand(env *cliEnv, args []string) int {
+	flags := env.newFlagSet("download", "[flags] <url|task-id>...")
+	var (
+		client clientFlags
+		dir    string
+	)
+	client.register(flags)
+	flags.StringVar(&dir, "o", ".", "directory to save files into")
+	if err := parseArgs(flags, args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	var runner api.Runner
+	code := 0
+	for _, arg := range flags.Args() {
+		// Task IDs and prediction URLs download the task's outputs; any other
+		// URL is downloaded as is.
+		outputs := []any{arg}
+		taskID, err := api.ParseTaskID(arg)
+		if err != nil && !isURL(arg) {
+			code = env.fail("%v", err)
+			continue
+		}
+		if err == nil {
+			if runner == nil {
+				runner = client.newClient()
+			}
+			pred, err := runner.GetPrediction(taskID)
+			if err != nil {
+				code = env.fail("%v", err)
+				continue
+			}
+			if pred.Status != "completed" {
+				code = env.fail("task %s is %s", taskID, pred.Status)
+				continue
+			}
+			outputs = pred.Outputs
+		}
+
+		files, err := downloadOutputs(dir, outputs)
+		for _, file := range files {
+			fmt.Fprintln(env.stdout, file)
+		}
+		if err != nil {
+			code = env.fail("%v", err)
+		}
+	}
+	return code
+}
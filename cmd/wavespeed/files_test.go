@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTestFiles(t *testing.T, dir string, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestExpandPaths(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, "a.png", "b.png", "c.txt", "refs/d.png", "refs/.hidden", "refs/nested/e.png")
+
+	files, err := expandPaths([]string{filepath.Join(dir, "*.png"), filepath.Join(dir, "refs"), filepath.Join(dir, "a.png")})
+	if err != nil {
+		t.Fatalf("expandPaths error: %v", err)
+	}
+
+	var rel []string
+	for _, file := range files {
+		r, _ := filepath.Rel(dir, file)
+		rel = append(rel, filepath.ToSlash(r))
+	}
+	if strings.Join(rel, ",") != "a.png,b.png,refs/d.png,refs/nested/e.png" {
+		t.Errorf("unexpected files: %v", rel)
+	}
+
+	if _, err := expandPaths([]string{filepath.Join(dir, "missing.png")}); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
+
+func TestUploadCommandJSON(t *testing.T) {
+	server := newTestServer(t, map[string]http.HandlerFunc{
+		"/api/v3/media/upload/binary": func(w http.ResponseWriter, r *http.Request) {
+			_, header, err := r.FormFile("file")
+			if err != nil {
+				http.Error(w, "no file", http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(`{"code":200,"message":"success","data":{"download_url":"https://example.com/` + header.Filename + `"}}`))
+		},
+	})
+
+	dir := t.TempDir()
+	writeTestFiles(t, dir, "a.png", "b.png")
+
+	env, stdout, stderr := newTestEnv("")
+	code := env.main([]string{"upload", filepath.Join(dir, "*.png"), "--api-key", "test-key", "--base-url", server.URL, "--json"})
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+
+	var urls map[string]string
+	if err := json.Unmarshal(stdout.Bytes(), &urls); err != nil {
+		t.Fatalf("invalid JSON output %q: %v", stdout.String(), err)
+	}
+	if urls[filepath.Join(dir, "a.png")] != "https://example.com/a.png" || urls[filepath.Join(dir, "b.png")] != "https://example.com/b.png" {
+		t.Errorf("unexpected mapping: %+v", urls)
+	}
+}
+
+func TestDownloadCommandByTaskID(t *testing.T) {
+	var server *httptest.Server
+	server = newTestServer(t, map[string]http.HandlerFunc{
+		"/api/v3/predictions/req-123/result": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"code":200,"data":{"id":"req-123","status":"completed","outputs":["` + server.URL + `/files/out.mp4"]}}`))
+		},
+		"/files/out.mp4": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("mp4 data"))
+		},
+	})
+
+	dir := t.TempDir()
+	env, stdout, stderr := newTestEnv("")
+	code := env.main([]string{"download", "req-123", "-o", dir, "--api-key", "test-key", "--base-url", server.URL})
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+
+	file := filepath.Join(dir, "out.mp4")
+	if strings.TrimSpace(stdout.String()) != file {
+		t.Errorf("expected downloaded path, got %q", stdout.String())
+	}
+	if data, err := os.ReadFile(file); err != nil || string(data) != "mp4 data" {
+		t.Errorf("unexpected file content %q: %v", data, err)
+	}
+}
+
+func TestDownloadCommandByPredictionURL(t *testing.T) {
+	var server *httptest.Server
+	server = newTestServer(t, map[string]http.HandlerFunc{
+		"/api/v3/predictions/req-123/result": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"code":200,"data":{"id":"req-123","status":"completed","outputs":["` + server.URL + `/files/out.mp4"]}}`))
+		},
+		"/files/out.mp4": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("mp4 data"))
+		},
+	})
+
+	dir := t.TempDir()
+	env, _, stderr := newTestEnv("")
+	ref := server.URL + "/api/v3/predictions/req-123/result"
+	code := env.main([]string{"download", ref, "-o", dir, "--api-key", "test-key", "--base-url", server.URL})
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "out.mp4")); err != nil || string(data) != "mp4 data" {
+		t.Errorf("expected the task's output, got %q: %v", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "result")); err == nil {
+		t.Errorf("expected the prediction URL not to be downloaded as a file")
+	}
+}
+
+func TestDownloadCommandLeavesNoPartialFile(t *testing.T) {
+	server := newTestServer(t, map[string]http.HandlerFunc{
+		"/files/out.mp4": func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Length", "100")
+			w.Write([]byte("truncated"))
+		},
+	})
+
+	dir := t.TempDir()
+	env, _, _ := newTestEnv("")
+	code := env.main([]string{"download", server.URL + "/files/out.mp4", "-o", dir})
+	if code == 0 {
+		t.Fatal("expected a failed download")
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("expected no files after a failed download, got %v", entries)
+	}
+}
@@ -0,0 +1,169 @@
+// Command wavespeed runs WaveSpeed models from the command line.
+//
+// Usage:
+//
+//	wavespeed <command> [flags] [args]
+//
+// Commands:
+//
+//	run       Run a model and wait for its outputs
+//	submit    Submit a task and print its ID
+//	status    Show the status of a task
+//	wait      Wait for a task to finish
+//	cancel    Cancel a task
+//	batch     Run a model over every line of a JSONL file
+//	upload    Upload files, directories or globs
+//	download  Download task outputs or URLs
+//	models    List models or show a model's inputs
+//	workflow  Run a workflow of models from a JSON or YAML definition
+//	balance   Show the account's credit balance
+//	usage     Show the account's spend, optionally by model or day
+//
+// Settings are read from the config file profile selected with --profile
+// (see api.LoadConfig), then from the WAVESPEED_* environment variables, then
+// from flags such as --api-key.
+// Run "wavespeed <command> -h" for the flags of a command.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/WaveSpeedAI/wavespeed-go/api"
+)
+
+type command struct {
+	name    string
+	summary string
+	run     func(env *cliEnv, args []string) int
+}
+
+var commands = []command{
+	{"run", "Run a model and wait for its outputs", runCommand},
+	{"submit", "Submit a task and print its ID", submitCommand},
+	{"status", "Show the status of a task", statusCommand},
+	{"wait", "Wait for a task to finish", waitCommand},
+	{"cancel", "Cancel a task", cancelCommand},
+	{"batch", "Run a model over every line of a JSONL file", batchCommand},
+	{"upload", "Upload files, directories or globs", uploadCommand},
+	{"download", "Download task outputs or URLs", downloadCommand},
+	{"models", "List models or show a model's inputs", modelsCommand},
+	{"workflow", "Run a workflow of models from a JSON or YAML definition", workflowCommand},
+	{"balance", "Show the account's credit balance", balanceCommand},
+	{"usage", "Show the account's spend, optionally by model or day", usageCommand},
+}
+
+// cliEnv holds the process streams so commands can be exercised in tests.
+type cliEnv struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+func main() {
+	env := &cliEnv{stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr}
+	os.Exit(env.main(os.Args[1:]))
+}
+
+func (env *cliEnv) main(args []string) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		env.usage()
+		if len(args) == 0 {
+			return 2
+		}
+		return 0
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			return cmd.run(env, args[1:])
+		}
+	}
+
+	fmt.Fprintf(env.stderr, "wavespeed: unknown command %q\n", args[0])
+	env.usage()
+	return 2
+}
+
+func (env *cliEnv) usage() {
+	fmt.Fprintln(env.stderr, "Usage: wavespeed <command> [flags] [args]")
+	fmt.Fprintln(env.stderr)
+	fmt.Fprintln(env.stderr, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(env.stderr, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+}
+
+// clientFlags are the connection flags shared by every command.
+type clientFlags struct {
+	profile string
+	apiKey  string
+	baseURL string
+	retries int
+	log     io.Writer
+}
+
+func (f *clientFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.profile, "profile", "", "config file profile (default: $WAVESPEED_PROFILE or default_profile)")
+	fs.StringVar(&f.apiKey, "api-key", "", "WaveSpeed API key (default: $WAVESPEED_API_KEY)")
+	fs.StringVar(&f.baseURL, "base-url", "", "API base URL")
+	fs.IntVar(&f.retries, "connection-retries", -1, "maximum HTTP connection retries")
+	// Retry messages go with the flag errors, keeping stdout for results.
+	f.log = fs.Output()
+}
+
+func (f *clientFlags) newClient() *api.Client {
+	opts := []api.ClientOption{api.WithProfile(f.profile), api.WithLogOutput(f.log)}
+	if f.apiKey != "" {
+		opts = append(opts, api.WithAPIKey(f.apiKey))
+	}
+	if f.baseURL != "" {
+		opts = append(opts, api.WithBaseURL(f.baseURL))
+	}
+	if f.retries >= 0 {
+		opts = append(opts, api.WithMaxConnectionRetries(f.retries))
+	}
+	return api.NewClient(opts...)
+}
+
+func (env *cliEnv) newFlagSet(name, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(env.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(env.stderr, "Usage: wavespeed %s %s\n\nFlags:\n", name, usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseArgs parses args like fs.Parse, but also accepts flags after
+// positional arguments, as in "wavespeed run <model> --set prompt=Cat".
+// Arguments after "--" are always positional. The positional arguments are
+// available from fs.Args afterwards.
+func parseArgs(fs *flag.FlagSet, args []string) error {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		rest := fs.Args()
+		if consumed := len(args) - len(rest); consumed > 0 && args[consumed-1] == "--" {
+			positional = append(positional, rest...)
+			break
+		}
+		if len(rest) == 0 {
+			break
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+	// Parsing a lone terminator resets fs.Args to the positional arguments.
+	return fs.Parse(append([]string{"--"}, positional...))
+}
+
+func (env *cliEnv) fail(format string, args ...any) int {
+	fmt.Fprintf(env.stderr, "wavespeed: "+format+"\n", args...)
+	return 1
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestEnv(stdin string) (*cliEnv, *bytes.Buffer, *bytes.Buffer) {
+	var stdout, stderr bytes.Buffer
+	return &cliEnv{stdin: strings.NewReader(stdin), stdout: &stdout, stderr: &stderr}, &stdout, &stderr
+}
+
+func newTestServer(t *testing.T, handlers map[string]http.HandlerFunc) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	for pattern, handler := range handlers {
+		mux.HandleFunc(pattern, handler)
+	}
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestReadInputMergesFileAndSets(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "input.json")
+	if err := os.WriteFile(file, []byte(`{"prompt":"Cat","seed":1}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	params, err := readInput(nil, file, []string{"seed=42", "prompt=Dog", "enable_safety_checker=false", "size=1024*1024"}, false)
+	if err != nil {
+		t.Fatalf("readInput error: %v", err)
+	}
+	if params["prompt"] != "Dog" {
+		t.Errorf("expected prompt=Dog, got %v", params["prompt"])
+	}
+	if params["seed"] != float64(42) {
+		t.Errorf("expected numeric seed, got %#v", params["seed"])
+	}
+	if params["enable_safety_checker"] != false {
+		t.Errorf("expected boolean flag, got %#v", params["enable_safety_checker"])
+	}
+	if params["size"] != "1024*1024" {
+		t.Errorf("expected string size, got %#v", params["size"])
+	}
+}
+
+func TestReadInputFromStdin(t *testing.T) {
+	params, err := readInput(strings.NewReader(`{"prompt":"Cat"}`), "-", nil, false)
+	if err != nil {
+		t.Fatalf("readInput error: %v", err)
+	}
+	if params["prompt"] != "Cat" {
+		t.Errorf("expected prompt=Cat, got %v", params["prompt"])
+	}
+
+	if _, err := readInput(strings.NewReader(`not json`), "-", nil, false); err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+}
+
+func TestRunCommandPrintsOutputs(t *testing.T) {
+	var body map[string]any
+	server := newTestServer(t, map[string]http.HandlerFunc{
+		"/api/v3/wavespeed-ai/z-image/turbo": func(w http.ResponseWriter, r *http.Request) {
+			json.NewDecoder(r.Body).Decode(&body)
+			w.Write([]byte(`{"code":200,"data":{"id":"req-123"}}`))
+		},
+		"/api/v3/predictions/req-123/result": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"code":200,"data":{"status":"completed","outputs":["https://example.com/out.png"]}}`))
+		},
+	})
+
+	env, stdout, stderr := newTestEnv("")
+	code := env.main([]string{"run", "wavespeed-ai/z-image/turbo", "--api-key", "test-key", "--base-url", server.URL, "--poll-interval", "0.01", "--set", "prompt=Cat"})
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	if strings.TrimSpace(stdout.String()) != "https://example.com/out.png" {
+		t.Errorf("unexpected output: %q", stdout.String())
+	}
+	if body["prompt"] != "Cat" {
+		t.Errorf("expected prompt in request body, got %+v", body)
+	}
+}
+
+func TestRunCommandKeepsStdoutCleanOnRetry(t *testing.T) {
+	t.Setenv("WAVESPEED_RETRY_INTERVAL", "0.01")
+	submissions := 0
+	server := newTestServer(t, map[string]http.HandlerFunc{
+		"/api/v3/wavespeed-ai/z-image/turbo": func(w http.ResponseWriter, r *http.Request) {
+			submissions++
+			if submissions == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				w.Write([]byte(`{"code":503,"message":"overloaded"}`))
+				return
+			}
+			w.Write([]byte(`{"code":200,"data":{"id":"req-123","status":"completed","outputs":["https://example.com/out.png"]}}`))
+		},
+	})
+
+	env, stdout, stderr := newTestEnv("")
+	code := env.main([]string{"run", "wavespeed-ai/z-image/turbo", "--set", "prompt=Cat", "--sync", "--retries", "1", "--json",
+		"--api-key", "test-key", "--base-url", server.URL})
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	var result map[string]any
+	if err := json.Unmarshal(stdout.Bytes(), &result); err != nil {
+		t.Fatalf("expected only JSON on stdout, got %q: %v", stdout.String(), err)
+	}
+	if !strings.Contains(stderr.String(), "Task attempt 1/2 failed") {
+		t.Errorf("expected retry message on stderr, got %q", stderr.String())
+	}
+}
+
+func TestRunCommandUsesConfiguredRetries(t *testing.T) {
+	t.Setenv("WAVESPEED_RETRY_INTERVAL", "0.01")
+	t.Setenv("WAVESPEED_MAX_RETRIES", "1")
+	submissions := 0
+	server := newTestServer(t, map[string]http.HandlerFunc{
+		"/api/v3/wavespeed-ai/z-image/turbo": func(w http.ResponseWriter, r *http.Request) {
+			submissions++
+			if submissions == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				w.Write([]byte(`{"code":503,"message":"overloaded"}`))
+				return
+			}
+			w.Write([]byte(`{"code":200,"data":{"id":"req-123","status":"completed","outputs":["https://example.com/out.png"]}}`))
+		},
+	})
+
+	// Without --retries, the retries from the environment apply.
+	env, _, stderr := newTestEnv("")
+	code := env.main([]string{"run", "wavespeed-ai/z-image/turbo", "--set", "prompt=Cat", "--sync",
+		"--api-key", "test-key", "--base-url", server.URL})
+	if code != 0 || submissions != 2 {
+		t.Fatalf("expected a retried success, got exit code %d after %d submissions: %s", code, submissions, stderr.String())
+	}
+
+	submissions = 0
+	env, _, _ = newTestEnv("")
+	code = env.main([]string{"run", "wavespeed-ai/z-image/turbo", "--set", "prompt=Cat", "--sync", "--retries", "0",
+		"--api-key", "test-key", "--base-url", server.URL})
+	if code != 1 || submissions != 1 {
+		t.Errorf("expected --retries 0 to disable retries, got exit code %d after %d submissions", code, submissions)
+	}
+}
+
+func TestRunCommandJSONFailure(t *testing.T) {
+	server := newTestServer(t, map[string]http.HandlerFunc{
+		"/api/v3/wavespeed-ai/z-image/turbo": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"code":200,"data":{"id":"req-123"}}`))
+		},
+		"/api/v3/predictions/req-123/result": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"code":200,"data":{"status":"failed","error":"Model error"}}`))
+		},
+	})
+
+	env, stdout, _ := newTestEnv("")
+	code := env.main([]string{"run", "--api-key", "test-key", "--base-url", server.URL, "--poll-interval", "0.01", "--json", "wavespeed-ai/z-image/turbo"})
+	if code != 1 {
+		t.Fatalf("expected exit code 1, got %d", code)
+	}
+
+	var result struct {
+		Outputs []any `json:"outputs"`
+		Detail  struct {
+			TaskID string `json:"taskId"`
+			Error  string `json:"error"`
+		} `json:"detail"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &result); err != nil {
+		t.Fatalf("invalid JSON output %q: %v", stdout.String(), err)
+	}
+	if result.Detail.TaskID != "req-123" || !strings.Contains(result.Detail.Error, "Model error") {
+		t.Errorf("unexpected detail: %+v", result.Detail)
+	}
+}
+
+func TestRunCommandDownload(t *testing.T) {
+	var server *httptest.Server
+	server = newTestServer(t, map[string]http.HandlerFunc{
+		"/api/v3/wavespeed-ai/z-image/turbo": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"code":200,"data":{"id":"req-123","status":"completed","outputs":["` + server.URL + `/files/out.png","text output"]}}`))
+		},
+		"/files/out.png": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("png data"))
+		},
+	})
+
+	dir := t.TempDir()
+	env, stdout, stderr := newTestEnv("")
+	code := env.main([]string{"run", "--api-key", "test-key", "--base-url", server.URL, "--sync", "--download", dir, "wavespeed-ai/z-image/turbo"})
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+
+	file := filepath.Join(dir, "out.png")
+	if strings.TrimSpace(stdout.String()) != file {
+		t.Errorf("expected downloaded path, got %q", stdout.String())
+	}
+	data, err := os.ReadFile(file)
+	if err != nil || string(data) != "png data" {
+		t.Errorf("unexpected file content %q: %v", data, err)
+	}
+}
+
+func TestParseArgsInterspersed(t *testing.T) {
+	env, _, _ := newTestEnv("")
+	fs := env.newFlagSet("test", "[flags] [args]")
+	concurrency := fs.Int("concurrency", 1, "")
+	out := fs.String("o", "", "")
+	asJSON := fs.Bool("json", false, "")
+
+	err := parseArgs(fs, []string{"refs/", "--concurrency", "8", "shots/*.png", "-o", "./out", "--json", "--", "-literal"})
+	if err != nil {
+		t.Fatalf("parseArgs error: %v", err)
+	}
+	if *concurrency != 8 || *out != "./out" || !*asJSON {
+		t.Errorf("unexpected flags: %d %q %v", *concurrency, *out, *asJSON)
+	}
+	if got := strings.Join(fs.Args(), " "); got != "refs/ shots/*.png -literal" {
+		t.Errorf("unexpected positional arguments: %q", got)
+	}
+
+	if err := parseArgs(fs, []string{"task", "--unknown"}); err == nil {
+		t.Error("expected error for unknown flag after a positional argument")
+	}
+}
+
+func TestUnknownCommand(t *testing.T) {
+	env, _, stderr := newTestEnv("")
+	if code := env.main([]string{"bogus"}); code != 2 {
+		t.Errorf("expected exit code 2, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "unknown command") {
+		t.Errorf("expected unknown command message, got %q", stderr.String())
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/WaveSpeedAI/wavespeed-go/api"
+)
+
+func modelsCommand(env *cliEnv, args []string) int {
+	fs := env.newFlagSet("models", "[flags] [model]")
+	var (
+		client   clientFlags
+		category string
+		asJSON   bool
+	)
+	client.register(fs)
+	fs.StringVar(&category, "category", "", "only list models in this category, e.g. text-to-image")
+	fs.BoolVar(&asJSON, "json", false, "print the models as JSON")
+	if err := parseArgs(fs, args); err != nil {
+		return 2
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return 2
+	}
+
+	if fs.NArg() == 1 {
+		model, err := client.newClient().GetModel(context.Background(), fs.Arg(0))
+		if err != nil {
+			return env.fail("%v", err)
+		}
+		if asJSON {
+			return env.writeJSONOrFail(model)
+		}
+		env.printModel(model)
+		return 0
+	}
+
+	models, err := client.newClient().ListModels(context.Background())
+	if err != nil {
+		return env.fail("%v", err)
+	}
+	var listed []api.Model
+	for _, m := range models {
+		if category == "" || m.Category == category {
+			listed = append(listed, m)
+		}
+	}
+
+	if asJSON {
+		if listed == nil {
+			listed = []api.Model{}
+		}
+		return env.writeJSONOrFail(listed)
+	}
+	tw := tabwriter.NewWriter(env.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "MODEL\tCATEGORY\tPRICE\tSYNC")
+	for _, m := range listed {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%v\n", m.ID, m.Category, formatPrice(m.Pricing), m.SyncMode)
+	}
+	tw.Flush()
+	return 0
+}
+
+func (env *cliEnv) printModel(m *api.Model) {
+	fmt.Fprintf(env.stdout, "Model:    %s\n", m.ID)
+	if m.Name != "" {
+		fmt.Fprintf(env.stdout, "Name:     %s\n", m.Name)
+	}
+	if m.Category != "" {
+		fmt.Fprintf(env.stdout, "Category: %s\n", m.Category)
+	}
+	fmt.Fprintf(env.stdout, "Price:    %s\n", formatPrice(m.Pricing))
+	fmt.Fprintf(env.stdout, "Sync:     %v\n", m.SyncMode)
+	if m.Description != "" {
+		fmt.Fprintf(env.stdout, "\n%s\n", m.Description)
+	}
+
+	if m.InputSchema == nil || len(m.InputSchema.Properties) == 0 {
+		return
+	}
+	required := make(map[string]bool)
+	for _, name := range m.InputSchema.Required {
+		required[name] = true
+	}
+	names := make([]string, 0, len(m.InputSchema.Properties))
+	for name := range m.InputSchema.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(env.stdout, "\nInputs:")
+	tw := tabwriter.NewWriter(env.stdout, 0, 4, 2, ' ', 0)
+	for _, name := range names {
+		prop := m.InputSchema.Properties[name]
+		var notes []string
+		if required[name] {
+			notes = append(notes, "required")
+		}
+		if len(prop.Enum) > 0 {
+			values := make([]string, len(prop.Enum))
+			for i, v := range prop.Enum {
+				values[i] = fmt.Sprint(v)
+			}
+			notes = append(notes, "one of "+strings.Join(values, ", "))
+		}
+		if prop.Default != nil {
+			notes = append(notes, fmt.Sprintf("default %v", prop.Default))
+		}
+		fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\n", name, prop.Type, strings.Join(notes, "; "), prop.Description)
+	}
+	tw.Flush()
+}
+
+func formatPrice(p api.Pricing) string {
+	if p.BasePrice == 0 && p.Unit == "" {
+		return "-"
+	}
+	price := fmt.Sprintf("%g", p.BasePrice)
+	if p.Currency != "" {
+		price += " " + p.Currency
+	}
+	if p.Unit != "" {
+		price += "/" + p.Unit
+	}
+	return price
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+const testModels = `{"code":200,"data":[
+	{"model_id":"wavespeed-ai/z-image/turbo","category":"text-to-image","sync_mode":true,
+	 "pricing":{"base_price":0.005,"currency":"USD","unit":"image"},
+	 "input_schema":{"type":"object","required":["prompt"],"properties":{
+		"prompt":{"type":"string","description":"Text prompt"},
+		"size":{"type":"string","enum":["512*512","1024*1024"],"default":"1024*1024"}}}},
+	{"model_id":"wavespeed-ai/wan-2.1/t2v-480p","category":"text-to-video",
+	 "pricing":{"base_price":0.05,"currency":"USD","unit":"second"}}
+]}`
+
+func TestModelsCommand(t *testing.T) {
+	server := newTestServer(t, map[string]http.HandlerFunc{
+		"/api/v3/models": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(testModels))
+		},
+	})
+
+	env, stdout, stderr := newTestEnv("")
+	code := env.main([]string{"models", "--api-key", "test-key", "--base-url", server.URL, "--category", "text-to-video"})
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	out := stdout.String()
+	if !strings.Contains(out, "wavespeed-ai/wan-2.1/t2v-480p") || strings.Contains(out, "z-image") {
+		t.Errorf("unexpected output: %q", out)
+	}
+	if !strings.Contains(out, "0.05 USD/second") {
+		t.Errorf("expected price in output: %q", out)
+	}
+
+	env, stdout, stderr = newTestEnv("")
+	code = env.main([]string{"models", "--api-key", "test-key", "--base-url", server.URL, "wavespeed-ai/z-image/turbo"})
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	out = stdout.String()
+	if !strings.Contains(out, "prompt") || !strings.Contains(out, "required") || !strings.Contains(out, "one of 512*512, 1024*1024") {
+		t.Errorf("expected inputs in output: %q", out)
+	}
+}
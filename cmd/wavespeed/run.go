@@ -0,0 +1,210 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/WaveSpeedAI/wavespeed-go/api"
+)
+
+// setFlags collects repeated --set key=value flags.
+type setFlags []string
+
+func (s *setFlags) String() string { return strings.Join(*s, ",") }
+
+func (s *setFlags) Set(value string) error {
+	if !strings.Contains(value, "=") {
+		return fmt.Errorf("expected key=value, got %q", value)
+	}
+	*s = append(*s, value)
+	return nil
+}
+
+// runFlags are the flags that map onto api.RunOptions.
+type runFlags struct {
+	timeout      float64
+	pollInterval float64
+	syncMode     bool
+	retries      int
+	validate     bool
+}
+
+func (f *runFlags) register(fs *flag.FlagSet) {
+	fs.Float64Var(&f.timeout, "timeout", 36000, "maximum time to wait for completion in seconds")
+	fs.Float64Var(&f.pollInterval, "poll-interval", 1, "interval between status checks in seconds")
+	fs.BoolVar(&f.syncMode, "sync", false, "enable sync mode, polling if the server-side sync wait times out")
+	fs.IntVar(&f.retries, "retries", -1, "maximum number of task-level retries")
+	fs.BoolVar(&f.validate, "validate", false, "check the input against the model's schema before submitting")
+}
+
+func (f *runFlags) options() []api.RunOption {
+	opts := []api.RunOption{
+		api.WithTimeout(f.timeout),
+		api.WithPollInterval(f.pollInterval),
+		api.WithSyncMode(f.syncMode),
+	}
+	if f.retries >= 0 {
+		opts = append(opts, api.WithMaxRetries(f.retries))
+	}
+	if f.syncMode {
+		opts = append(opts, api.WithSyncFallback())
+	}
+	if f.validate {
+		opts = append(opts, api.WithValidation())
+	}
+	return opts
+}
+
+// inputFlags are the flags that build the model input.
+type inputFlags struct {
+	sets setFlags
+	file string
+}
+
+func (f *inputFlags) register(fs *flag.FlagSet) {
+	fs.Var(&f.sets, "set", "input parameter as key=value; may be repeated")
+	fs.StringVar(&f.file, "input", "", "JSON file with input parameters, or - for stdin")
+}
+
+func (f *inputFlags) read(stdin io.Reader) (map[string]any, error) {
+	return readInput(stdin, f.file, f.sets, len(f.sets) == 0 && f.file == "")
+}
+
+func runCommand(env *cliEnv, args []string) int {
+	fs := env.newFlagSet("run", "[flags] <model>")
+	var (
+		client   clientFlags
+		run      runFlags
+		input    inputFlags
+		asJSON   bool
+		download string
+	)
+	client.register(fs)
+	run.register(fs)
+	input.register(fs)
+	fs.BoolVar(&asJSON, "json", false, "print the prediction as JSON")
+	fs.StringVar(&download, "download", "", "download outputs into this directory")
+	if err := parseArgs(fs, args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	model := fs.Arg(0)
+
+	params, err := input.read(env.stdin)
+	if err != nil {
+		return env.fail("%v", err)
+	}
+
+	result := client.newClient().RunNoThrow(model, params, run.options()...)
+	if result.Outputs != nil && download != "" {
+		files, err := downloadOutputs(download, result.Outputs)
+		if err != nil {
+			return env.fail("%v", err)
+		}
+		if !asJSON {
+			for _, file := range files {
+				fmt.Fprintln(env.stdout, file)
+			}
+			return 0
+		}
+	}
+
+	if asJSON {
+		if err := writeJSON(env.stdout, result); err != nil {
+			return env.fail("%v", err)
+		}
+	} else if result.Outputs != nil {
+		printOutputs(env.stdout, result.Outputs)
+	}
+
+	if result.Outputs == nil {
+		return env.fail("task %s %s: %s", result.Detail.TaskID, result.Detail.Status, result.Detail.Error)
+	}
+	return 0
+}
+
+// readInput builds the model input from an optional JSON file (or stdin) and
+// --set overrides. When fromPipe is true and stdin is not a terminal, stdin
+// is read as JSON.
+func readInput(stdin io.Reader, file string, sets []string, fromPipe bool) (map[string]any, error) {
+	params := map[string]any{}
+
+	var r io.Reader
+	switch {
+	case file == "-":
+		r = stdin
+	case file != "":
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	case fromPipe && isPipe(stdin):
+		r = stdin
+	}
+
+	if r != nil {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		if len(strings.TrimSpace(string(data))) > 0 {
+			if err := json.Unmarshal(data, &params); err != nil {
+				return nil, fmt.Errorf("invalid input JSON: %w", err)
+			}
+		}
+	}
+
+	for _, set := range sets {
+		key, value, _ := strings.Cut(set, "=")
+		params[key] = parseValue(value)
+	}
+	return params, nil
+}
+
+// parseValue interprets a --set value as JSON when possible so that numbers,
+// booleans, arrays and objects keep their type; anything else is a string.
+func parseValue(value string) any {
+	var v any
+	if err := json.Unmarshal([]byte(value), &v); err == nil {
+		return v
+	}
+	return value
+}
+
+func isPipe(r io.Reader) bool {
+	f, ok := r.(*os.File)
+	if !ok {
+		return r != nil
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice == 0
+}
+
+func printOutputs(w io.Writer, outputs []any) {
+	for _, output := range outputs {
+		if s, ok := output.(string); ok {
+			fmt.Fprintln(w, s)
+			continue
+		}
+		data, _ := json.Marshal(output)
+		fmt.Fprintln(w, string(data))
+	}
+}
+
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
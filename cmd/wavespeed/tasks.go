@@ -0,0 +1,236 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/WaveSpeedAI/wavespeed-go/api"
+)
+
+func submitCommand(env *cliEnv, args []string) int {
+	fs := env.newFlagSet("submit", "[flags] <model>")
+	var (
+		client         clientFlags
+		input          inputFlags
+		retries        int
+		idempotencyKey string
+		validate       bool
+		asJSON         bool
+	)
+	client.register(fs)
+	input.register(fs)
+	fs.IntVar(&retries, "retries", -1, "maximum number of task-level retries")
+	fs.StringVar(&idempotencyKey, "idempotency-key", "", "key that makes resubmitting the same task safe")
+	fs.BoolVar(&validate, "validate", false, "check the input against the model's schema before submitting")
+	fs.BoolVar(&asJSON, "json", false, "print the task as JSON")
+	if err := parseArgs(fs, args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	model := fs.Arg(0)
+
+	params, err := input.read(env.stdin)
+	if err != nil {
+		return env.fail("%v", err)
+	}
+
+	opts := []api.RunOption{api.WithIdempotencyKey(idempotencyKey)}
+	if retries >= 0 {
+		opts = append(opts, api.WithMaxRetries(retries))
+	}
+	if validate {
+		opts = append(opts, api.WithValidation())
+	}
+	taskID, err := client.newClient().Submit(model, params, opts...)
+	if err != nil {
+		return env.fail("%v", err)
+	}
+
+	if asJSON {
+		return env.writeJSONOrFail(api.RunDetail{TaskID: taskID, Status: "created", Model: model})
+	}
+	fmt.Fprintln(env.stdout, taskID)
+	return 0
+}
+
+func statusCommand(env *cliEnv, args []string) int {
+	fs := env.newFlagSet("status", "[flags] <task-id|result-url>")
+	var (
+		client clientFlags
+		asJSON bool
+	)
+	client.register(fs)
+	fs.BoolVar(&asJSON, "json", false, "print the prediction as JSON")
+	if err := parseArgs(fs, args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	taskID, err := api.ParseTaskID(fs.Arg(0))
+	if err != nil {
+		return env.fail("%v", err)
+	}
+
+	pred, err := client.newClient().GetPrediction(taskID)
+	if err != nil {
+		return env.fail("%v", err)
+	}
+
+	if asJSON {
+		return env.writeJSONOrFail(pred)
+	}
+	fmt.Fprintf(env.stdout, "Task:    %s\n", pred.ID)
+	if pred.Model != "" {
+		fmt.Fprintf(env.stdout, "Model:   %s\n", pred.Model)
+	}
+	fmt.Fprintf(env.stdout, "Status:  %s\n", pred.Status)
+	if pred.CreatedAt != "" {
+		fmt.Fprintf(env.stdout, "Created: %s\n", pred.CreatedAt)
+	}
+	if pred.Error != "" {
+		fmt.Fprintf(env.stdout, "Error:   %s\n", pred.Error)
+	}
+	if len(pred.Outputs) > 0 {
+		fmt.Fprintln(env.stdout, "Outputs:")
+		printOutputs(env.stdout, pred.Outputs)
+	}
+	return 0
+}
+
+func waitCommand(env *cliEnv, args []string) int {
+	fs := env.newFlagSet("wait", "[flags] <task-id|result-url>")
+	var (
+		client       clientFlags
+		timeout      float64
+		pollInterval float64
+		asJSON       bool
+	)
+	client.register(fs)
+	fs.Float64Var(&timeout, "timeout", 36000, "maximum time to wait for completion in seconds")
+	fs.Float64Var(&pollInterval, "poll-interval", 1, "interval between status checks in seconds")
+	fs.BoolVar(&asJSON, "json", false, "print the prediction as JSON")
+	if err := parseArgs(fs, args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	taskID, err := api.ParseTaskID(fs.Arg(0))
+	if err != nil {
+		return env.fail("%v", err)
+	}
+
+	start := time.Now()
+	stop := env.startSpinner("Waiting for task "+taskID, start)
+	result := client.newClient().Attach(context.Background(), taskID, api.WithTimeout(timeout), api.WithPollInterval(pollInterval))
+	stop()
+
+	if asJSON {
+		if code := env.writeJSONOrFail(result); code != 0 {
+			return code
+		}
+	} else {
+		fmt.Fprintf(env.stderr, "Task %s %s after %s\n", taskID, result.Detail.Status, time.Since(start).Round(time.Second))
+		printOutputs(env.stdout, result.Outputs)
+	}
+
+	if result.Outputs == nil {
+		return env.fail("%s", result.Detail.Error)
+	}
+	return 0
+}
+
+func cancelCommand(env *cliEnv, args []string) int {
+	fs := env.newFlagSet("cancel", "[flags] <task-id|result-url>")
+	var (
+		client clientFlags
+		asJSON bool
+	)
+	client.register(fs)
+	fs.BoolVar(&asJSON, "json", false, "print the result as JSON")
+	if err := parseArgs(fs, args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	taskID, err := api.ParseTaskID(fs.Arg(0))
+	if err != nil {
+		return env.fail("%v", err)
+	}
+
+	if err := client.newClient().Cancel(taskID); err != nil {
+		return env.fail("%v", err)
+	}
+
+	if asJSON {
+		return env.writeJSONOrFail(api.RunDetail{TaskID: taskID, Status: "canceled"})
+	}
+	fmt.Fprintf(env.stdout, "Canceled %s\n", taskID)
+	return 0
+}
+
+func (env *cliEnv) writeJSONOrFail(v any) int {
+	if err := writeJSON(env.stdout, v); err != nil {
+		return env.fail("%v", err)
+	}
+	return 0
+}
+
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// startSpinner draws a spinner with the elapsed time on stderr until the
+// returned function is called. Nothing is drawn when stderr is not a terminal.
+func (env *cliEnv) startSpinner(message string, start time.Time) func() {
+	if !isTerminal(env.stderr) {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(100 * time.Millisecond)
+		defer ticker.Stop()
+		for frame := 0; ; frame++ {
+			elapsed := time.Since(start).Round(time.Second)
+			fmt.Fprintf(env.stderr, "\r%s %s (%s)\033[K", spinnerFrames[frame%len(spinnerFrames)], message, elapsed)
+			select {
+			case <-done:
+				fmt.Fprint(env.stderr, "\r\033[K")
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
+
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
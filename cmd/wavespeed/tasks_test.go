@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestSubmitCommandPrintsTaskID(t *testing.T) {
+	server := newTestServer(t, map[string]http.HandlerFunc{
+		"/api/v3/wavespeed-ai/wan-2.1/t2v": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"code":200,"data":{"id":"req-123","status":"created"}}`))
+		},
+	})
+
+	env, stdout, stderr := newTestEnv("")
+	code := env.main([]string{"submit", "--api-key", "test-key", "--base-url", server.URL, "--set", "prompt=Cat", "wavespeed-ai/wan-2.1/t2v"})
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	if strings.TrimSpace(stdout.String()) != "req-123" {
+		t.Errorf("expected task ID, got %q", stdout.String())
+	}
+}
+
+func TestStatusCommandJSON(t *testing.T) {
+	server := newTestServer(t, map[string]http.HandlerFunc{
+		"/api/v3/predictions/req-123/result": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"code":200,"data":{"id":"req-123","status":"processing","outputs":[]}}`))
+		},
+	})
+
+	env, stdout, stderr := newTestEnv("")
+	code := env.main([]string{"status", "--api-key", "test-key", "--base-url", server.URL, "--json", "req-123"})
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+
+	var pred map[string]any
+	if err := json.Unmarshal(stdout.Bytes(), &pred); err != nil {
+		t.Fatalf("invalid JSON output %q: %v", stdout.String(), err)
+	}
+	if pred["status"] != "processing" {
+		t.Errorf("expected status=processing, got %v", pred["status"])
+	}
+}
+
+func TestWaitCommand(t *testing.T) {
+	polls := 0
+	server := newTestServer(t, map[string]http.HandlerFunc{
+		"/api/v3/predictions/req-123/result": func(w http.ResponseWriter, r *http.Request) {
+			polls++
+			if polls < 3 {
+				w.Write([]byte(`{"code":200,"data":{"status":"processing"}}`))
+				return
+			}
+			w.Write([]byte(`{"code":200,"data":{"status":"completed","outputs":["https://example.com/out.mp4"]}}`))
+		},
+	})
+
+	env, stdout, stderr := newTestEnv("")
+	code := env.main([]string{"wait", "req-123", "--api-key", "test-key", "--base-url", server.URL, "--poll-interval", "0.01"})
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	if strings.TrimSpace(stdout.String()) != "https://example.com/out.mp4" {
+		t.Errorf("unexpected output: %q", stdout.String())
+	}
+	if !strings.Contains(stderr.String(), "completed after") {
+		t.Errorf("expected elapsed time on stderr, got %q", stderr.String())
+	}
+}
+
+func TestCancelCommand(t *testing.T) {
+	server := newTestServer(t, map[string]http.HandlerFunc{
+		"/api/v3/predictions/req-123/cancel": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"code":200,"message":"success"}`))
+		},
+	})
+
+	env, stdout, stderr := newTestEnv("")
+	code := env.main([]string{"cancel", "--api-key", "test-key", "--base-url", server.URL, "--json", "req-123"})
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), `"status": "canceled"`) {
+		t.Errorf("unexpected output: %q", stdout.String())
+	}
+}
+
+func TestWaitCommandAcceptsResultURL(t *testing.T) {
+	server := newTestServer(t, map[string]http.HandlerFunc{
+		"/api/v3/predictions/req-123/result": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"code":200,"data":{"id":"req-123","model":"wavespeed-ai/z-image/turbo","status":"completed","outputs":["https://example.com/out.png"]}}`))
+		},
+	})
+
+	env, stdout, stderr := newTestEnv("")
+	code := env.main([]string{"wait", "--api-key", "test-key", "--base-url", server.URL, "--json",
+		"https://api.wavespeed.ai/api/v3/predictions/req-123/result"})
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), `"taskId": "req-123"`) || !strings.Contains(stdout.String(), `"model": "wavespeed-ai/z-image/turbo"`) {
+		t.Errorf("unexpected output: %q", stdout.String())
+	}
+}
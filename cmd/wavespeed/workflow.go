@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/WaveSpeedAI/wavespeed-go/workflow"
+)
+
+func workflowCommand(env *cliEnv, args []string) int {
+	fs := env.newFlagSet("workflow", "[flags] <workflow.json|workflow.yaml>")
+	var (
+		client      clientFlags
+		run         runFlags
+		input       inputFlags
+		concurrency int
+		rate        float64
+		asJSON      bool
+	)
+	client.register(fs)
+	run.register(fs)
+	input.register(fs)
+	fs.IntVar(&concurrency, "concurrency", 4, "number of model calls to run in parallel")
+	fs.Float64Var(&rate, "rate", 0, "maximum submissions per second (0 for unlimited)")
+	fs.BoolVar(&asJSON, "json", false, "print the result of every node as JSON")
+	if err := parseArgs(fs, args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	var (
+		data []byte
+		err  error
+	)
+	if file := fs.Arg(0); file == "-" {
+		data, err = io.ReadAll(env.stdin)
+	} else {
+		data, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return env.fail("%v", err)
+	}
+	def, err := workflow.Parse(data)
+	if err != nil {
+		return env.fail("%v", err)
+	}
+
+	params, err := input.read(env.stdin)
+	if err != nil {
+		return env.fail("%v", err)
+	}
+
+	exec := workflow.NewExecutor(client.newClient(),
+		workflow.WithConcurrency(concurrency),
+		workflow.WithRate(rate),
+		workflow.WithRunOptions(run.options()...),
+	)
+	result, runErr := exec.Run(context.Background(), def, params)
+
+	if asJSON {
+		if err := writeJSON(env.stdout, result); err != nil {
+			return env.fail("%v", err)
+		}
+	} else {
+		for _, n := range def.Nodes {
+			nr, ok := result.Nodes[n.ID]
+			if !ok {
+				continue
+			}
+			fmt.Fprintf(env.stdout, "%s: %s\n", n.ID, nr.Status)
+			printOutputs(env.stdout, nr.Outputs)
+		}
+	}
+
+	if runErr != nil {
+		return env.fail("%v", runErr)
+	}
+	return 0
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestWorkflowCommand(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		inputs = map[string][]map[string]any{}
+		nextID int
+	)
+	submit := func(model string, outputs string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			var input map[string]any
+			json.NewDecoder(r.Body).Decode(&input)
+			mu.Lock()
+			inputs[model] = append(inputs[model], input)
+			nextID++
+			id := fmt.Sprintf("%s-%d", model, nextID)
+			mu.Unlock()
+			fmt.Fprintf(w, `{"code":200,"data":{"id":%q,"status":"completed","outputs":%s}}`, id, outputs)
+		}
+	}
+	server := newTestServer(t, map[string]http.HandlerFunc{
+		"/api/v3/gen":     submit("gen", `["a.png","b.png"]`),
+		"/api/v3/upscale": submit("upscale", `["big.png"]`),
+	})
+
+	def := filepath.Join(t.TempDir(), "workflow.json")
+	if err := os.WriteFile(def, []byte(`{"nodes": [
+		{"id": "generate", "model": "gen", "input": {"prompt": "{{input.prompt}}"}},
+		{"id": "upscale", "model": "upscale", "for_each": "{{generate.outputs}}", "input": {"image": "{{item}}"}}
+	]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	env, stdout, stderr := newTestEnv("")
+	code := env.main([]string{"workflow", "--api-key", "test-key", "--base-url", server.URL, "--sync", "--set", "prompt=Cat", def})
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	want := "generate: completed\na.png\nb.png\nupscale: completed\nbig.png\nbig.png\n"
+	if stdout.String() != want {
+		t.Errorf("unexpected output:\n%s", stdout.String())
+	}
+	if len(inputs["gen"]) != 1 || inputs["gen"][0]["prompt"] != "Cat" {
+		t.Errorf("unexpected generate inputs: %v", inputs["gen"])
+	}
+	if len(inputs["upscale"]) != 2 {
+		t.Errorf("expected an upscale per image, got %v", inputs["upscale"])
+	}
+
+	// Invalid definitions are reported before anything runs.
+	if err := os.WriteFile(def, []byte(`{"nodes": [{"id": "a", "model": "gen", "input": {"x": "{{b.output}}"}}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	env, _, stderr = newTestEnv("")
+	code = env.main([]string{"workflow", "--api-key", "test-key", "--base-url", server.URL, def})
+	if code != 1 || !strings.Contains(stderr.String(), "unknown node b") {
+		t.Errorf("expected validation failure, got %d: %s", code, stderr.String())
+	}
+}
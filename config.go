@@ -1,6 +1,9 @@
 package wavespeed
 
-import "os"
+import (
+	"os"
+	"strconv"
+)
 
 // APIConfig holds API client configuration options.
 type APIConfig struct {
@@ -27,12 +30,41 @@ type APIConfig struct {
 }
 
 // API is the global API configuration instance.
-var API = &APIConfig{
-	APIKey:               os.Getenv("WAVESPEED_API_KEY"),
-	BaseURL:              "https://api.wavespeed.ai",
-	ConnectionTimeout:    10.0,
-	Timeout:              36000.0,
-	MaxRetries:           0,
-	MaxConnectionRetries: 5,
-	RetryInterval:        1.0,
+//
+// Defaults can be overridden with the WAVESPEED_* environment variables.
+var API = newAPIConfig()
+
+// newAPIConfig returns the default configuration with the environment
+// overrides applied.
+func newAPIConfig() *APIConfig {
+	return &APIConfig{
+		APIKey:               os.Getenv("WAVESPEED_API_KEY"),
+		BaseURL:              envString("WAVESPEED_BASE_URL", "https://api.wavespeed.ai"),
+		ConnectionTimeout:    envFloat("WAVESPEED_CONNECTION_TIMEOUT", 10.0),
+		Timeout:              envFloat("WAVESPEED_TIMEOUT", 36000.0),
+		MaxRetries:           envInt("WAVESPEED_MAX_RETRIES", 0),
+		MaxConnectionRetries: envInt("WAVESPEED_MAX_CONNECTION_RETRIES", 5),
+		RetryInterval:        envFloat("WAVESPEED_RETRY_INTERVAL", 1.0),
+	}
+}
+
+func envString(name, fallback string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func envFloat(name string, fallback float64) float64 {
+	if f, err := strconv.ParseFloat(os.Getenv(name), 64); err == nil {
+		return f
+	}
+	return fallback
+}
+
+func envInt(name string, fallback int) int {
+	if i, err := strconv.Atoi(os.Getenv(name)); err == nil {
+		return i
+	}
+	return fallback
 }
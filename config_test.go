@@ -9,6 +9,15 @@ func TestAPIConfigHasExpectedAttributes(t *testing.T) {
 		t.Fatal("API config should not be nil")
 	}
 
+	// Check the defaults, whatever the environment of the test run.
+	for _, name := range []string{
+		"WAVESPEED_BASE_URL", "WAVESPEED_CONNECTION_TIMEOUT", "WAVESPEED_TIMEOUT",
+		"WAVESPEED_MAX_RETRIES", "WAVESPEED_MAX_CONNECTION_RETRIES", "WAVESPEED_RETRY_INTERVAL",
+	} {
+		t.Setenv(name, "")
+	}
+	API := newAPIConfig()
+
 	if API.BaseURL != "https://api.wavespeed.ai" {
 		t.Errorf("Expected BaseURL to be 'https://api.wavespeed.ai', got '%s'", API.BaseURL)
 	}
@@ -33,3 +42,19 @@ func TestAPIConfigHasExpectedAttributes(t *testing.T) {
 		t.Errorf("Expected RetryInterval to be 1.0, got %f", API.RetryInterval)
 	}
 }
+
+func TestEnvOverrides(t *testing.T) {
+	t.Setenv("WAVESPEED_BASE_URL", "https://staging.api.wavespeed.ai")
+	t.Setenv("WAVESPEED_MAX_RETRIES", "3")
+	t.Setenv("WAVESPEED_RETRY_INTERVAL", "not-a-number")
+
+	if got := envString("WAVESPEED_BASE_URL", "https://api.wavespeed.ai"); got != "https://staging.api.wavespeed.ai" {
+		t.Errorf("Expected BaseURL override, got '%s'", got)
+	}
+	if got := envInt("WAVESPEED_MAX_RETRIES", 0); got != 3 {
+		t.Errorf("Expected MaxRetries override to be 3, got %d", got)
+	}
+	if got := envFloat("WAVESPEED_RETRY_INTERVAL", 1.0); got != 1.0 {
+		t.Errorf("Expected invalid RetryInterval to fall back to 1.0, got %f", got)
+	}
+}
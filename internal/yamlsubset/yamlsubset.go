@@ -0,0 +1,322 @@
+// Package yamlsubset parses the subset of YAML used by workflow definitions
+// and config files: block mappings and sequences, flow sequences of scalars,
+// and plain, quoted, numeric, boolean and null scalars. Flow mappings, block
+// scalars, anchors, aliases and tags are rejected. Strings starting with "{"
+// must be quoted, as in any YAML document.
+package yamlsubset
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// srcLine is a non-empty line of a YAML document without its comment.
+type srcLine struct {
+	num    int
+	indent int
+	text   string
+}
+
+// Parse parses data into map[string]any, []any, string, int64, float64, bool
+// and nil values. An empty document yields nil.
+func Parse(data string) (any, error) {
+	return parse(data, false)
+}
+
+// ParseStrings is Parse with plain scalars kept as strings, so that values
+// such as "0123" or "1e10" survive unchanged. Quoted strings are unquoted.
+func ParseStrings(data string) (any, error) {
+	return parse(data, true)
+}
+
+func parse(data string, strs bool) (any, error) {
+	var lines []srcLine
+	for i, raw := range strings.Split(data, "\n") {
+		raw = strings.TrimRight(stripComment(raw), " \r")
+		text := strings.TrimLeft(raw, " ")
+		if text == "" || text == "---" {
+			continue
+		}
+		if strings.HasPrefix(text, "\t") {
+			return nil, fmt.Errorf("line %d: tabs are not allowed for indentation", i+1)
+		}
+		lines = append(lines, srcLine{num: i + 1, indent: len(raw) - len(text), text: text})
+	}
+	if len(lines) == 0 {
+		return nil, nil
+	}
+
+	p := &parser{lines: lines, strs: strs}
+	value, err := p.block(lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.lines) {
+		return nil, fmt.Errorf("line %d: unexpected indentation", p.lines[p.pos].num)
+	}
+	return value, nil
+}
+
+type parser struct {
+	lines []srcLine
+	pos   int
+	strs  bool // keep plain scalars as strings
+}
+
+// block parses the mapping or sequence starting at the current line.
+func (p *parser) block(indent int) (any, error) {
+	if isSeqItem(p.lines[p.pos].text) {
+		return p.sequence(indent)
+	}
+	return p.mapping(indent)
+}
+
+func (p *parser) sequence(indent int) (any, error) {
+	list := []any{}
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if line.indent < indent || (line.indent == indent && !isSeqItem(line.text)) {
+			break
+		}
+		if line.indent > indent {
+			return nil, fmt.Errorf("line %d: unexpected indentation", line.num)
+		}
+
+		rest := strings.TrimLeft(strings.TrimPrefix(line.text, "-"), " ")
+		switch {
+		case rest == "":
+			p.pos++
+			if p.pos >= len(p.lines) || p.lines[p.pos].indent <= indent {
+				list = append(list, nil)
+				continue
+			}
+			item, err := p.block(p.lines[p.pos].indent)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, item)
+		case isSeqItem(rest) || hasMappingKey(rest):
+			// "- key: value" starts a block at the column of the key.
+			column := line.indent + len(line.text) - len(rest)
+			p.lines[p.pos] = srcLine{num: line.num, indent: column, text: rest}
+			item, err := p.block(column)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, item)
+		default:
+			item, err := p.scalar(rest)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line.num, err)
+			}
+			list = append(list, item)
+			p.pos++
+		}
+	}
+	return list, nil
+}
+
+func (p *parser) mapping(indent int) (any, error) {
+	m := map[string]any{}
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if line.indent < indent {
+			break
+		}
+		if line.indent > indent {
+			return nil, fmt.Errorf("line %d: unexpected indentation", line.num)
+		}
+		if isSeqItem(line.text) {
+			return nil, fmt.Errorf("line %d: expected key: value", line.num)
+		}
+
+		key, value, ok := splitMappingKey(line.text)
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key: value", line.num)
+		}
+		if unquoted, err := p.scalar(key); err == nil {
+			if s, isString := unquoted.(string); isString {
+				key = s
+			}
+		}
+		if _, dup := m[key]; dup {
+			return nil, fmt.Errorf("line %d: duplicate key %q", line.num, key)
+		}
+		p.pos++
+
+		if value != "" {
+			v, err := p.scalar(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line.num, err)
+			}
+			m[key] = v
+			continue
+		}
+
+		// A nested block is indented further, except that a sequence may
+		// sit at the same indentation as its key.
+		if p.pos < len(p.lines) {
+			next := p.lines[p.pos]
+			if next.indent > indent || (next.indent == indent && isSeqItem(next.text)) {
+				v, err := p.block(next.indent)
+				if err != nil {
+					return nil, err
+				}
+				m[key] = v
+				continue
+			}
+		}
+		m[key] = nil
+	}
+	return m, nil
+}
+
+func isSeqItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+func hasMappingKey(text string) bool {
+	_, _, ok := splitMappingKey(text)
+	return ok
+}
+
+// splitMappingKey splits "key: value" at the first colon outside quotes that
+// ends the line or is followed by a space.
+func splitMappingKey(text string) (key, value string, ok bool) {
+	if strings.HasPrefix(text, "[") || strings.HasPrefix(text, "{") {
+		return "", "", false
+	}
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case (c == '"' || c == '\'') && i == 0:
+			quote = c
+		case c == ':' && (i == len(text)-1 || text[i+1] == ' '):
+			key = strings.TrimSpace(text[:i])
+			return key, strings.TrimSpace(text[i+1:]), key != ""
+		}
+	}
+	return "", "", false
+}
+
+func (p *parser) scalar(value string) (any, error) {
+	switch {
+	case strings.HasPrefix(value, `"`):
+		s, err := strconv.Unquote(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid string %s", value)
+		}
+		return s, nil
+	case strings.HasPrefix(value, "'"):
+		if len(value) < 2 || !strings.HasSuffix(value, "'") {
+			return nil, fmt.Errorf("invalid string %s", value)
+		}
+		return strings.ReplaceAll(value[1:len(value)-1], "''", "'"), nil
+	case strings.HasPrefix(value, "["):
+		return p.flowSequence(value)
+	case strings.HasPrefix(value, "{"):
+		return nil, fmt.Errorf("flow mappings are not supported; quote strings such as %q", value)
+	case strings.HasPrefix(value, "|") || strings.HasPrefix(value, ">"):
+		return nil, errors.New("block scalars are not supported; use a quoted string")
+	case strings.HasPrefix(value, "&") || strings.HasPrefix(value, "*") || strings.HasPrefix(value, "!"):
+		return nil, fmt.Errorf("anchors, aliases and tags are not supported: %s", value)
+	}
+
+	if p.strs {
+		return value, nil
+	}
+	switch value {
+	case "null", "Null", "NULL", "~":
+		return nil, nil
+	case "true", "True", "TRUE":
+		return true, nil
+	case "false", "False", "FALSE":
+		return false, nil
+	}
+	if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return i, nil
+	}
+	if f, err := strconv.ParseFloat(value, 64); err == nil && !strings.ContainsAny(value, "xXpP_") {
+		return f, nil
+	}
+	return value, nil
+}
+
+// flowSequence parses a single-line list of scalars such as [a, "b"].
+func (p *parser) flowSequence(value string) (any, error) {
+	if !strings.HasSuffix(value, "]") {
+		return nil, fmt.Errorf("invalid list %s", value)
+	}
+	inner := strings.TrimSpace(value[1 : len(value)-1])
+	list := []any{}
+	if inner == "" {
+		return list, nil
+	}
+
+	var items []string
+	var quote byte
+	start := 0
+	for i := 0; i < len(inner); i++ {
+		c := inner[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case (c == '"' || c == '\'') && strings.TrimSpace(inner[start:i]) == "":
+			quote = c
+		case c == '[' || c == '{':
+			return nil, fmt.Errorf("nested lists and mappings are not supported: %s", value)
+		case c == ',':
+			items = append(items, inner[start:i])
+			start = i + 1
+		}
+	}
+	items = append(items, inner[start:])
+
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			return nil, fmt.Errorf("invalid list %s", value)
+		}
+		v, err := p.scalar(item)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, v)
+	}
+	return list, nil
+}
+
+// stripComment removes a # comment that starts a line or follows a space and
+// is not inside a quoted string.
+func stripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case (c == '"' || c == '\'') && (i == 0 || strings.IndexByte(" [,", line[i-1]) >= 0):
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' '):
+			return line[:i]
+		}
+	}
+	return line
+}
@@ -0,0 +1,88 @@
+package yamlsubset
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	value, err := Parse(`
+name: it's # not a comment start for quotes
+count: 2
+ratio: 0.5
+enabled: true
+missing: ~
+url: https://example.com/a.png
+tags: [a, "b, c", 3]
+list:
+- one
+-
+  nested: yes
+items:
+  - - x
+    - y
+`)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	want := map[string]any{
+		"name":    "it's",
+		"count":   int64(2),
+		"ratio":   0.5,
+		"enabled": true,
+		"missing": nil,
+		"url":     "https://example.com/a.png",
+		"tags":    []any{"a", "b, c", int64(3)},
+		"list":    []any{"one", map[string]any{"nested": "yes"}},
+		"items":   []any{[]any{"x", "y"}},
+	}
+	if !reflect.DeepEqual(value, want) {
+		t.Errorf("unexpected value:\n%#v\nwant\n%#v", value, want)
+	}
+}
+
+func TestParseStrings(t *testing.T) {
+	value, err := ParseStrings(`
+profiles:
+  prod:
+    api_key: 0123
+    timeout: 1e10
+    enabled: true
+    name: "quoted # value"
+`)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	want := map[string]any{
+		"profiles": map[string]any{
+			"prod": map[string]any{
+				"api_key": "0123",
+				"timeout": "1e10",
+				"enabled": "true",
+				"name":    "quoted # value",
+			},
+		},
+	}
+	if !reflect.DeepEqual(value, want) {
+		t.Errorf("unexpected value:\n%#v\nwant\n%#v", value, want)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		doc  string
+		want string
+	}{
+		{"input: {prompt: x}\n", "flow mappings are not supported"},
+		{"a:\n  b: 1\n   c: 2\n", "line 3: unexpected indentation"},
+		{"a: 1\na: 2\n", `duplicate key "a"`},
+		{"prompt: |\n  text\n", "block scalars are not supported"},
+		{"a:\n\tb: 1\n", "tabs are not allowed"},
+	}
+	for _, tt := range tests {
+		if _, err := Parse(tt.doc); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Parse(%q): expected error containing %q, got %v", tt.doc, tt.want, err)
+		}
+	}
+}
@@ -0,0 +1,313 @@
+// Package pipeline chains WaveSpeed models so that the outputs of one step
+// feed the inputs of the next, such as text-to-image, then upscale, then
+// image-to-video.
+//
+// Example:
+//
+//	p := pipeline.New([]pipeline.Step{
+//	    {
+//	        Name:  "image",
+//	        Model: "wavespeed-ai/z-image/turbo",
+//	        Input: pipeline.Fields(map[string]any{"prompt": pipeline.Param("prompt")}),
+//	    },
+//	    {
+//	        Name:  "upscale",
+//	        Model: "wavespeed-ai/image-upscaler",
+//	        Input: pipeline.Fields(map[string]any{"image": pipeline.Output("image")}),
+//	    },
+//	    {
+//	        Name:       "video",
+//	        Model:      "wavespeed-ai/wan-2.1/i2v-480p",
+//	        Input:      pipeline.Fields(map[string]any{"image": pipeline.Previous(), "prompt": pipeline.Param("prompt")}),
+//	        MaxRetries: 2,
+//	    },
+//	})
+//
+//	result, err := p.Run(ctx, client, map[string]any{"prompt": "Cat surfing"})
+//	if err != nil {
+//	    // result holds the steps that succeeded; retry from the failed one.
+//	    result, err = p.Resume(ctx, client, result)
+//	}
+//	fmt.Println(result.Outputs())
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/WaveSpeedAI/wavespeed-go/api"
+)
+
+// InputFunc builds the input of a step from the pipeline input and the
+// results of the steps before it.
+type InputFunc func(s *State) (map[string]any, error)
+
+// Step is a single model invocation in a pipeline.
+type Step struct {
+	// Name identifies the step in State lookups and results. It defaults to
+	// the model ID and must be unique within a pipeline.
+	Name  string
+	Model string
+	// Input builds the step input. The pipeline input is used as is when nil.
+	Input InputFunc
+	// Options are passed to Run for this step, e.g. api.WithTimeout.
+	Options []api.RunOption
+	// MaxRetries is the number of times a failed step is run again.
+	MaxRetries int
+	// RetryInterval is the base delay between attempts, multiplied by the
+	// attempt number (1 second by default).
+	RetryInterval time.Duration
+}
+
+func (s Step) name() string {
+	if s.Name != "" {
+		return s.Name
+	}
+	return s.Model
+}
+
+// StepResult records the outcome of a step.
+type StepResult struct {
+	Step       string         `json:"step"`
+	Model      string         `json:"model"`
+	TaskID     string         `json:"task_id,omitempty"`
+	Input      map[string]any `json:"input,omitempty"`
+	Outputs    []any          `json:"outputs,omitempty"`
+	Error      string         `json:"error,omitempty"`
+	Attempts   int            `json:"attempts"`
+	StartedAt  time.Time      `json:"started_at"`
+	FinishedAt time.Time      `json:"finished_at"`
+}
+
+// Succeeded reports whether the step completed.
+func (r StepResult) Succeeded() bool {
+	return r.Error == "" && !r.FinishedAt.IsZero()
+}
+
+// Result holds the pipeline input and the results of the steps run so far.
+// It encodes to JSON, so it can be saved and passed to Resume later, even
+// from another process.
+type Result struct {
+	Input map[string]any `json:"input"`
+	Steps []StepResult   `json:"steps"`
+}
+
+// Outputs returns the outputs of the last step, or nil if the pipeline has
+// not completed.
+func (r *Result) Outputs() []any {
+	if len(r.Steps) == 0 {
+		return nil
+	}
+	last := r.Steps[len(r.Steps)-1]
+	if !last.Succeeded() {
+		return nil
+	}
+	return last.Outputs
+}
+
+// Step returns the result of the named step, or nil if it has not run.
+func (r *Result) Step(name string) *StepResult {
+	for i := range r.Steps {
+		if r.Steps[i].Step == name {
+			return &r.Steps[i]
+		}
+	}
+	return nil
+}
+
+// StepError is returned when a step fails after all its attempts.
+type StepError struct {
+	Step   string
+	TaskID string
+	Err    error
+}
+
+func (e *StepError) Error() string {
+	if e.TaskID != "" && e.TaskID != "unknown" {
+		return fmt.Sprintf("step %s failed (task_id: %s): %v", e.Step, e.TaskID, e.Err)
+	}
+	return fmt.Sprintf("step %s failed: %v", e.Step, e.Err)
+}
+
+func (e *StepError) Unwrap() error {
+	return e.Err
+}
+
+// Option configures a Pipeline.
+type Option func(*Pipeline)
+
+// WithProgress calls fn with the result so far after every step attempt, for
+// example to save it for Resume.
+func WithProgress(fn func(r *Result)) Option {
+	return func(p *Pipeline) {
+		p.progress = fn
+	}
+}
+
+// WithLogOutput writes a message to w before each step retry. By default
+// nothing is written.
+func WithLogOutput(w io.Writer) Option {
+	return func(p *Pipeline) {
+		p.logOutput = w
+	}
+}
+
+// Pipeline runs a fixed sequence of steps.
+type Pipeline struct {
+	steps     []Step
+	progress  func(r *Result)
+	logOutput io.Writer
+}
+
+// New creates a pipeline from steps, run in order.
+func New(steps []Step, opts ...Option) *Pipeline {
+	p := &Pipeline{steps: append([]Step(nil), steps...)}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Run runs every step with input as the pipeline input.
+//
+// The returned result is never nil: when a step fails it holds the steps
+// that succeeded and the failed one, and can be passed to Resume. Cancelling
+// ctx stops the running step.
+func (p *Pipeline) Run(ctx context.Context, client api.Runner, input map[string]any) (*Result, error) {
+	return p.Resume(ctx, client, &Result{Input: input})
+}
+
+// Resume continues a pipeline from a previous result, keeping the steps that
+// succeeded and running the rest. The steps must be the same, in the same
+// order, as when the result was produced.
+func (p *Pipeline) Resume(ctx context.Context, client api.Runner, prev *Result) (*Result, error) {
+	result := &Result{}
+	if prev != nil {
+		result.Input = prev.Input
+	}
+	if err := p.validate(); err != nil {
+		return result, err
+	}
+
+	// Keep the leading steps that succeeded.
+	if prev != nil {
+		for i, sr := range prev.Steps {
+			if i >= len(p.steps) || !sr.Succeeded() {
+				break
+			}
+			if sr.Step != p.steps[i].name() {
+				return result, fmt.Errorf("result does not match pipeline: step %d is %s, expected %s", i+1, sr.Step, p.steps[i].name())
+			}
+			result.Steps = append(result.Steps, sr)
+		}
+	}
+
+	for i := len(result.Steps); i < len(p.steps); i++ {
+		if err := p.runStep(ctx, client, p.steps[i], result); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+func (p *Pipeline) validate() error {
+	if len(p.steps) == 0 {
+		return errors.New("pipeline has no steps")
+	}
+	seen := make(map[string]bool, len(p.steps))
+	for i, step := range p.steps {
+		if step.Model == "" {
+			return fmt.Errorf("step %d has no model", i+1)
+		}
+		if seen[step.name()] {
+			return fmt.Errorf("duplicate step name: %s", step.name())
+		}
+		seen[step.name()] = true
+	}
+	return nil
+}
+
+// runStep runs step, retrying as configured, and appends its result.
+func (p *Pipeline) runStep(ctx context.Context, client api.Runner, step Step, result *Result) error {
+	name := step.name()
+	sr := StepResult{Step: name, Model: step.Model, StartedAt: time.Now()}
+	result.Steps = append(result.Steps, sr)
+	current := &result.Steps[len(result.Steps)-1]
+
+	fail := func(taskID string, err error) error {
+		current.TaskID = taskID
+		current.Error = err.Error()
+		current.FinishedAt = time.Now()
+		p.report(result)
+		return &StepError{Step: name, TaskID: taskID, Err: err}
+	}
+
+	input := result.Input
+	if step.Input != nil {
+		var err error
+		state := &State{Input: result.Input, Results: result.Steps[:len(result.Steps)-1]}
+		if input, err = step.Input(state); err != nil {
+			return fail("", fmt.Errorf("failed to build input: %w", err))
+		}
+	}
+	current.Input = input
+
+	interval := step.RetryInterval
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	for attempt := 0; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return fail(current.TaskID, err)
+		}
+
+		current.Attempts = attempt + 1
+		run := client.RunNoThrowContext(ctx, step.Model, input, step.Options...)
+		if run.Outputs != nil {
+			current.TaskID = run.Detail.TaskID
+			current.Outputs = run.Outputs
+			current.Error = ""
+			current.FinishedAt = time.Now()
+			p.report(result)
+			return nil
+		}
+
+		if err := ctx.Err(); err != nil {
+			return fail(run.Detail.TaskID, err)
+		}
+		err := errors.New(run.Detail.Error)
+		if attempt >= step.MaxRetries {
+			return fail(run.Detail.TaskID, err)
+		}
+		current.TaskID = run.Detail.TaskID
+		current.Error = err.Error()
+		p.report(result)
+
+		delay := interval * time.Duration(attempt+1)
+		p.logf("Step %s attempt %d/%d failed: %v\n", name, attempt+1, step.MaxRetries+1, err)
+		p.logf("Retrying in %.1f seconds...\n", delay.Seconds())
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fail(current.TaskID, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+func (p *Pipeline) logf(format string, args ...any) {
+	if p.logOutput != nil {
+		fmt.Fprintf(p.logOutput, format, args...)
+	}
+}
+
+func (p *Pipeline) report(result *Result) {
+	if p.progress != nil {
+		p.progress(result)
+	}
+}
@@ -0,0 +1,196 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/WaveSpeedAI/wavespeed-go/api"
+	"github.com/WaveSpeedAI/wavespeed-go/api/apitest"
+)
+
+const (
+	imageModel   = "wavespeed-ai/z-image/turbo"
+	upscaleModel = "wavespeed-ai/image-upscaler"
+	videoModel   = "wavespeed-ai/wan-2.1/i2v-480p"
+)
+
+func newTestPipeline(opts ...Option) *Pipeline {
+	return New([]Step{
+		{
+			Name:  "image",
+			Model: imageModel,
+			Input: Fields(map[string]any{"prompt": Param("prompt")}),
+		},
+		{
+			Name:  "upscale",
+			Model: upscaleModel,
+			Input: Fields(map[string]any{"image": Output("image"), "scale": 2}),
+		},
+		{
+			Name:          "video",
+			Model:         videoModel,
+			Input:         Fields(map[string]any{"image": Previous(), "prompt": Param("prompt")}),
+			MaxRetries:    1,
+			RetryInterval: time.Millisecond,
+		},
+	}, opts...)
+}
+
+func TestPipelineRun(t *testing.T) {
+	mock := apitest.NewMock()
+	mock.Enqueue(imageModel, apitest.Result{TaskID: "t1", Outputs: []any{"https://example.com/cat.png"}})
+	mock.Enqueue(upscaleModel, apitest.Result{TaskID: "t2", Outputs: []any{"https://example.com/cat-4x.png"}})
+	mock.Enqueue(videoModel,
+		apitest.Result{TaskID: "t3", Err: errors.New("overloaded")},
+		apitest.Result{TaskID: "t4", Outputs: []any{"https://example.com/cat.mp4"}},
+	)
+
+	var reports int
+	var log bytes.Buffer
+	p := newTestPipeline(WithProgress(func(r *Result) { reports++ }), WithLogOutput(&log))
+	result, err := p.Run(context.Background(), mock, map[string]any{"prompt": "Cat"})
+	if err != nil {
+		t.Fatalf("run error: %v", err)
+	}
+	if !strings.Contains(log.String(), "Step video attempt 1/2 failed: overloaded") {
+		t.Errorf("expected the retry to be logged, got %q", log.String())
+	}
+
+	if outputs := result.Outputs(); len(outputs) != 1 || outputs[0] != "https://example.com/cat.mp4" {
+		t.Errorf("unexpected outputs: %v", outputs)
+	}
+	calls := mock.Calls()
+	if len(calls) != 4 {
+		t.Fatalf("expected 4 calls, got %d", len(calls))
+	}
+	if calls[1].Input["image"] != "https://example.com/cat.png" || calls[1].Input["scale"] != 2 {
+		t.Errorf("unexpected upscale input: %v", calls[1].Input)
+	}
+	if calls[3].Input["image"] != "https://example.com/cat-4x.png" || calls[3].Input["prompt"] != "Cat" {
+		t.Errorf("unexpected video input: %v", calls[3].Input)
+	}
+
+	video := result.Step("video")
+	if video == nil || video.Attempts != 2 || video.TaskID != "t4" || !video.Succeeded() {
+		t.Errorf("unexpected video step: %+v", video)
+	}
+	if reports != 4 {
+		t.Errorf("expected a progress report per attempt, got %d", reports)
+	}
+}
+
+func TestPipelineResume(t *testing.T) {
+	mock := apitest.NewMock()
+	mock.Enqueue(imageModel, apitest.Result{TaskID: "t1", Outputs: []any{"https://example.com/cat.png"}})
+	mock.Enqueue(upscaleModel, apitest.Result{TaskID: "t2", Err: errors.New("invalid image")})
+
+	p := newTestPipeline()
+	result, err := p.Run(context.Background(), mock, map[string]any{"prompt": "Cat"})
+	var stepErr *StepError
+	if !errors.As(err, &stepErr) || stepErr.Step != "upscale" || stepErr.TaskID != "t2" {
+		t.Fatalf("expected upscale step error, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "invalid image") {
+		t.Errorf("expected cause in error, got %v", err)
+	}
+	if len(result.Steps) != 2 || result.Steps[1].Succeeded() || result.Outputs() != nil {
+		t.Errorf("unexpected partial result: %+v", result)
+	}
+
+	// The result survives a round trip through JSON, as when saved to disk.
+	data, err := json.Marshal(result)
+	if err != nil {
+		t.Fatalf("failed to encode result: %v", err)
+	}
+	var saved Result
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatalf("failed to decode result: %v", err)
+	}
+
+	mock.Reset()
+	mock.Enqueue(upscaleModel, apitest.Result{TaskID: "t3", Outputs: []any{"https://example.com/cat-4x.png"}})
+	mock.Enqueue(videoModel, apitest.Result{TaskID: "t4", Outputs: []any{"https://example.com/cat.mp4"}})
+	result, err = p.Resume(context.Background(), mock, &saved)
+	if err != nil {
+		t.Fatalf("resume error: %v", err)
+	}
+	calls := mock.Calls()
+	if len(calls) != 2 || calls[0].Model != upscaleModel {
+		t.Fatalf("expected only the remaining steps to run, got %+v", calls)
+	}
+	if calls[0].Input["image"] != "https://example.com/cat.png" {
+		t.Errorf("expected upscale input from the saved image step, got %v", calls[0].Input)
+	}
+	if result.Step("image").TaskID != "t1" || len(result.Outputs()) != 1 {
+		t.Errorf("unexpected resumed result: %+v", result)
+	}
+}
+
+func TestPipelineErrors(t *testing.T) {
+	mock := apitest.NewMock()
+	ctx := context.Background()
+
+	if _, err := New(nil).Run(ctx, mock, nil); err == nil {
+		t.Error("expected error for empty pipeline")
+	}
+	if _, err := New([]Step{{Model: imageModel}, {Model: imageModel}}).Run(ctx, mock, nil); err == nil || !strings.Contains(err.Error(), "duplicate step name") {
+		t.Errorf("expected duplicate step error, got %v", err)
+	}
+
+	// Mapping errors fail the step without calling the model.
+	p := New([]Step{{Model: imageModel, Input: Fields(map[string]any{"prompt": Param("prompt")})}})
+	if _, err := p.Run(ctx, mock, map[string]any{}); err == nil || !strings.Contains(err.Error(), "missing pipeline input: prompt") {
+		t.Errorf("expected missing input error, got %v", err)
+	}
+	if len(mock.Calls()) != 0 {
+		t.Errorf("expected no calls, got %d", len(mock.Calls()))
+	}
+
+	// A result from a different pipeline is rejected.
+	other := &Result{Steps: []StepResult{{Step: "other", FinishedAt: time.Now()}}}
+	if _, err := newTestPipeline().Resume(ctx, mock, other); err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Errorf("expected mismatch error, got %v", err)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := p.Run(canceled, mock, map[string]any{"prompt": "Cat"}); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestPipelineRunCancelsRunningStep(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/"+imageModel, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"code":200,"data":{"id":"req-1"}}`))
+	})
+	mux.HandleFunc("/api/v3/predictions/req-1/result", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"code":200,"data":{"status":"processing"}}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := api.NewClient(api.WithAPIKey("test-key"), api.WithBaseURL(server.URL))
+	p := New([]Step{{Name: "image", Model: imageModel, Options: []api.RunOption{api.WithPollInterval(0.01)}}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	result, err := p.Run(ctx, client, map[string]any{"prompt": "Cat"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected the step to stop at cancellation, took %v", elapsed)
+	}
+	if step := result.Step("image"); step == nil || step.Succeeded() {
+		t.Errorf("expected a failed step, got %+v", step)
+	}
+}
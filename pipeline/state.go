@@ -0,0 +1,131 @@
+package pipeline
+
+import "fmt"
+
+// State is what an InputFunc sees: the pipeline input and the results of the
+// steps that ran before the current one.
+type State struct {
+	Input   map[string]any
+	Results []StepResult
+}
+
+// Outputs returns the outputs of the named step.
+func (s *State) Outputs(step string) ([]any, error) {
+	for _, r := range s.Results {
+		if r.Step == step {
+			return r.Outputs, nil
+		}
+	}
+	return nil, fmt.Errorf("step %s has not run", step)
+}
+
+// Output returns output index of the named step.
+func (s *State) Output(step string, index int) (any, error) {
+	outputs, err := s.Outputs(step)
+	if err != nil {
+		return nil, err
+	}
+	if index < 0 || index >= len(outputs) {
+		return nil, fmt.Errorf("step %s has %d outputs, no output %d", step, len(outputs), index)
+	}
+	return outputs[index], nil
+}
+
+// Previous returns the result of the step before the current one, or nil for
+// the first step.
+func (s *State) Previous() *StepResult {
+	if len(s.Results) == 0 {
+		return nil
+	}
+	return &s.Results[len(s.Results)-1]
+}
+
+// Value is a reference resolved against the State when a step input is
+// built with Fields.
+type Value func(s *State) (any, error)
+
+// Output refers to the first output of the named step.
+func Output(step string) Value {
+	return OutputAt(step, 0)
+}
+
+// OutputAt refers to output index of the named step.
+func OutputAt(step string, index int) Value {
+	return func(s *State) (any, error) {
+		return s.Output(step, index)
+	}
+}
+
+// Previous refers to the first output of the step before the current one.
+func Previous() Value {
+	return func(s *State) (any, error) {
+		prev := s.Previous()
+		if prev == nil {
+			return nil, fmt.Errorf("no previous step")
+		}
+		return s.Output(prev.Step, 0)
+	}
+}
+
+// Param refers to a field of the pipeline input.
+func Param(name string) Value {
+	return func(s *State) (any, error) {
+		v, ok := s.Input[name]
+		if !ok {
+			return nil, fmt.Errorf("missing pipeline input: %s", name)
+		}
+		return v, nil
+	}
+}
+
+// Fields builds a step input from fields, resolving Value entries, including
+// those nested in maps and slices, and copying everything else as is.
+//
+// Example:
+//
+//	pipeline.Fields(map[string]any{
+//	    "image":    pipeline.Output("image"),
+//	    "prompt":   pipeline.Param("prompt"),
+//	    "duration": 5,
+//	})
+func Fields(fields map[string]any) InputFunc {
+	return func(s *State) (map[string]any, error) {
+		input := make(map[string]any, len(fields))
+		for k, v := range fields {
+			resolved, err := resolve(s, v)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", k, err)
+			}
+			input[k] = resolved
+		}
+		return input, nil
+	}
+}
+
+func resolve(s *State, v any) (any, error) {
+	switch v := v.(type) {
+	case Value:
+		return v(s)
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, item := range v {
+			resolved, err := resolve(s, item)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", k, err)
+			}
+			out[k] = resolved
+		}
+		return out, nil
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			resolved, err := resolve(s, item)
+			if err != nil {
+				return nil, fmt.Errorf("%d: %w", i, err)
+			}
+			out[i] = resolved
+		}
+		return out, nil
+	}
+	return v, nil
+}
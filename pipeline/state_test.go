@@ -0,0 +1,32 @@
+package pipeline
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFields(t *testing.T) {
+	state := &State{
+		Input:   map[string]any{"prompt": "Cat"},
+		Results: []StepResult{{Step: "image", Outputs: []any{"a.png", "b.png"}}},
+	}
+	input, err := Fields(map[string]any{
+		"images": []any{Output("image"), OutputAt("image", 1)},
+		"meta":   map[string]any{"prompt": Param("prompt")},
+		"fixed":  true,
+	})(state)
+	if err != nil {
+		t.Fatalf("fields error: %v", err)
+	}
+	images := input["images"].([]any)
+	if images[0] != "a.png" || images[1] != "b.png" || input["meta"].(map[string]any)["prompt"] != "Cat" || input["fixed"] != true {
+		t.Errorf("unexpected input: %v", input)
+	}
+
+	if _, err := Fields(map[string]any{"image": OutputAt("image", 5)})(state); err == nil || !strings.Contains(err.Error(), "image: step image has 2 outputs") {
+		t.Errorf("expected output index error, got %v", err)
+	}
+	if _, err := Fields(map[string]any{"image": Output("missing")})(state); err == nil || !strings.Contains(err.Error(), "has not run") {
+		t.Errorf("expected missing step error, got %v", err)
+	}
+}
@@ -7,6 +7,12 @@ import (
 // Client is the WaveSpeed API client.
 type Client = api.Client
 
+// Runner is the interface implemented by Client, useful for substituting fakes in tests.
+type Runner = api.Runner
+
+// Prediction is the state of a task as reported by the API.
+type Prediction = api.Prediction
+
 // RunOption configures optional parameters for Run.
 type RunOption = api.RunOption
 
@@ -21,8 +27,24 @@ var (
 	WithPollInterval = api.WithPollInterval
 	// WithSyncMode enables or disables synchronous mode.
 	WithSyncMode = api.WithSyncMode
+	// WithSyncFallback keeps polling after a server-side sync timeout.
+	WithSyncFallback = api.WithSyncFallback
 	// WithMaxRetries sets the maximum number of task-level retries.
 	WithMaxRetries = api.WithMaxRetries
+	// WithIdempotencyKey makes resubmitting the same task safe.
+	WithIdempotencyKey = api.WithIdempotencyKey
+	// WithValidation checks the input against the model's schema before submitting.
+	WithValidation = api.WithValidation
+	// WithFallbackModels tries other models when a model is overloaded or times out.
+	WithFallbackModels = api.WithFallbackModels
+	// WithInputAdapter rewrites the input for a fallback model.
+	WithInputAdapter = api.WithInputAdapter
+	// WithRoutingKey assigns a run to a Router variant by key instead of at random.
+	WithRoutingKey = api.WithRoutingKey
+	// WithPollStrategy sets how long to wait between status checks.
+	WithPollStrategy = api.WithPollStrategy
+	// WithPollTimeout sets the HTTP timeout for each status check.
+	WithPollTimeout = api.WithPollTimeout
 	// WithUploadTimeout sets the timeout for file upload.
 	WithUploadTimeout = api.WithUploadTimeout
 )
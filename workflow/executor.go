@@ -0,0 +1,379 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/WaveSpeedAI/wavespeed-go/api"
+)
+
+// Func is a Go function node. It receives the resolved node input and
+// returns the node outputs.
+type Func func(ctx context.Context, input map[string]any) ([]any, error)
+
+// NodeStatus is the outcome of a node.
+type NodeStatus string
+
+const (
+	NodeCompleted NodeStatus = "completed"
+	NodeFailed    NodeStatus = "failed"
+	// NodeSkipped marks a node that did not run because a node it depends on
+	// did not complete.
+	NodeSkipped NodeStatus = "skipped"
+)
+
+// NodeResult records the outcome of a node.
+type NodeResult struct {
+	Node    string     `json:"node"`
+	Status  NodeStatus `json:"status"`
+	Outputs []any      `json:"outputs,omitempty"`
+	// TaskIDs are the tasks of a model node, one per ForEach item.
+	TaskIDs    []string  `json:"task_ids,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	StartedAt  time.Time `json:"started_at,omitempty"`
+	FinishedAt time.Time `json:"finished_at,omitempty"`
+
+	err error
+}
+
+// Result holds the outcome of every node of a workflow run.
+type Result struct {
+	Nodes map[string]*NodeResult `json:"nodes"`
+}
+
+// Outputs returns the outputs of a completed node.
+func (r *Result) Outputs(node string) []any {
+	if n, ok := r.Nodes[node]; ok && n.Status == NodeCompleted {
+		return n.Outputs
+	}
+	return nil
+}
+
+// NodeError is returned when a node fails.
+type NodeError struct {
+	Node string
+	Err  error
+}
+
+func (e *NodeError) Error() string {
+	return fmt.Sprintf("node %s failed: %v", e.Node, e.Err)
+}
+
+func (e *NodeError) Unwrap() error {
+	return e.Err
+}
+
+// Option configures an Executor.
+type Option func(*Executor)
+
+// WithFunc registers fn as the function node name.
+func WithFunc(name string, fn Func) Option {
+	return func(e *Executor) {
+		e.funcs[name] = fn
+	}
+}
+
+// WithConcurrency sets the maximum number of model calls and functions
+// running at once across the workflow (4 by default).
+func WithConcurrency(n int) Option {
+	return func(e *Executor) {
+		e.concurrency = n
+	}
+}
+
+// WithRate limits model submissions to perSecond (unlimited by default).
+func WithRate(perSecond float64) Option {
+	return func(e *Executor) {
+		e.rate = perSecond
+	}
+}
+
+// WithRunOptions sets run options used for every model node, e.g.
+// api.WithSyncMode(true). Node MaxRetries and Timeout are applied after them.
+func WithRunOptions(opts ...api.RunOption) Option {
+	return func(e *Executor) {
+		e.runOptions = append(e.runOptions, opts...)
+	}
+}
+
+// Executor runs workflows with a client. It is safe for concurrent use.
+type Executor struct {
+	client      api.Runner
+	funcs       map[string]Func
+	concurrency int
+	rate        float64
+	runOptions  []api.RunOption
+}
+
+// NewExecutor creates an executor that runs model nodes with client.
+func NewExecutor(client api.Runner, opts ...Option) *Executor {
+	e := &Executor{
+		client:      client,
+		funcs:       make(map[string]Func),
+		concurrency: 4,
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	if e.concurrency < 1 {
+		e.concurrency = 1
+	}
+	return e
+}
+
+// Run runs def with input as the workflow input.
+//
+// Nodes start as soon as their dependencies complete. When a node fails, the
+// nodes that depend on it are skipped while independent branches run to the
+// end, and the first failure is returned as a *NodeError. The returned result
+// is never nil.
+func (e *Executor) Run(ctx context.Context, def *Definition, input map[string]any) (*Result, error) {
+	result := &Result{Nodes: make(map[string]*NodeResult, len(def.Nodes))}
+	if err := def.Validate(); err != nil {
+		return result, err
+	}
+	for _, n := range def.Nodes {
+		if n.Func != "" && e.funcs[n.Func] == nil {
+			return result, fmt.Errorf("node %s uses unknown func %s", n.ID, n.Func)
+		}
+	}
+	if input == nil {
+		input = map[string]any{}
+	}
+
+	r := &run{
+		executor: e,
+		ctx:      ctx,
+		input:    input,
+		outputs:  make(map[string][]any),
+		slots:    make(chan struct{}, e.concurrency),
+	}
+	if e.rate > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / e.rate))
+		defer ticker.Stop()
+		r.ticks = ticker.C
+	}
+
+	nodes := make(map[string]Node, len(def.Nodes))
+	waiting := make(map[string]int, len(def.Nodes))
+	dependents := make(map[string][]string)
+	for _, n := range def.Nodes {
+		nodes[n.ID] = n
+		deps, _ := n.dependencies()
+		waiting[n.ID] = len(deps)
+		for _, dep := range deps {
+			dependents[dep] = append(dependents[dep], n.ID)
+		}
+	}
+
+	done := make(chan *NodeResult)
+	running := 0
+	start := func(n Node) {
+		running++
+		go func() { done <- r.node(n) }()
+	}
+
+	// skip marks id and everything downstream of it as skipped.
+	var skip func(id, reason string)
+	skip = func(id, reason string) {
+		if _, ok := result.Nodes[id]; ok {
+			return
+		}
+		result.Nodes[id] = &NodeResult{Node: id, Status: NodeSkipped, Error: reason}
+		for _, next := range dependents[id] {
+			skip(next, fmt.Sprintf("node %s did not complete", id))
+		}
+	}
+
+	for _, n := range def.Nodes {
+		if waiting[n.ID] == 0 {
+			start(n)
+		}
+	}
+
+	var firstErr error
+	for running > 0 {
+		nr := <-done
+		running--
+		result.Nodes[nr.Node] = nr
+
+		if nr.Status != NodeCompleted {
+			if firstErr == nil {
+				firstErr = &NodeError{Node: nr.Node, Err: nr.err}
+			}
+			for _, next := range dependents[nr.Node] {
+				skip(next, fmt.Sprintf("node %s failed", nr.Node))
+			}
+			continue
+		}
+
+		r.mu.Lock()
+		r.outputs[nr.Node] = nr.Outputs
+		r.mu.Unlock()
+		for _, next := range dependents[nr.Node] {
+			waiting[next]--
+			if waiting[next] == 0 {
+				if _, skipped := result.Nodes[next]; !skipped {
+					start(nodes[next])
+				}
+			}
+		}
+	}
+
+	if firstErr == nil && ctx.Err() != nil {
+		firstErr = ctx.Err()
+	}
+	return result, firstErr
+}
+
+// run is the state of a single workflow run.
+type run struct {
+	executor *Executor
+	ctx      context.Context
+	input    map[string]any
+	slots    chan struct{}
+	ticks    <-chan time.Time
+
+	mu      sync.Mutex
+	outputs map[string][]any
+}
+
+// node runs n, once or once per ForEach item, and reports its result.
+func (r *run) node(n Node) *NodeResult {
+	nr := &NodeResult{Node: n.ID, StartedAt: time.Now()}
+	fail := func(err error) *NodeResult {
+		nr.Status = NodeFailed
+		nr.Error = err.Error()
+		nr.err = err
+		nr.FinishedAt = time.Now()
+		return nr
+	}
+
+	r.mu.Lock()
+	base := scope{input: r.input, outputs: make(map[string][]any, len(r.outputs))}
+	for id, outputs := range r.outputs {
+		base.outputs[id] = outputs
+	}
+	r.mu.Unlock()
+
+	// Without ForEach the node is a single call.
+	items := []any{nil}
+	if n.ForEach != "" {
+		ref, _, _ := parseWholeRef(n.ForEach)
+		value, err := base.lookup(ref)
+		if err != nil {
+			return fail(fmt.Errorf("for_each: %w", err))
+		}
+		list, ok := value.([]any)
+		if !ok {
+			return fail(fmt.Errorf("for_each: %s is not a list", n.ForEach))
+		}
+		items = list
+	}
+
+	type call struct {
+		outputs []any
+		taskID  string
+		err     error
+	}
+	calls := make([]call, len(items))
+	var wg sync.WaitGroup
+	for i, item := range items {
+		s := base
+		s.item = item
+		s.index = i
+
+		resolved, err := s.resolve(nodeInput(n.Input))
+		if err != nil {
+			calls[i].err = fmt.Errorf("failed to build input: %w", err)
+			continue
+		}
+		input := resolved.(map[string]any)
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			calls[i].outputs, calls[i].taskID, calls[i].err = r.call(n, input)
+		}(i)
+	}
+	wg.Wait()
+
+	nr.Outputs = []any{}
+	for i, c := range calls {
+		if c.taskID != "" {
+			nr.TaskIDs = append(nr.TaskIDs, c.taskID)
+		}
+		if c.err != nil {
+			if len(items) > 1 {
+				return fail(fmt.Errorf("item %d: %w", i, c.err))
+			}
+			return fail(c.err)
+		}
+		nr.Outputs = append(nr.Outputs, c.outputs...)
+	}
+	nr.Status = NodeCompleted
+	nr.FinishedAt = time.Now()
+	return nr
+}
+
+func nodeInput(input map[string]any) map[string]any {
+	if input == nil {
+		return map[string]any{}
+	}
+	return input
+}
+
+// call runs a single model call or function once a slot is free.
+func (r *run) call(n Node, input map[string]any) ([]any, string, error) {
+	select {
+	case r.slots <- struct{}{}:
+	case <-r.ctx.Done():
+		return nil, "", r.ctx.Err()
+	}
+	defer func() { <-r.slots }()
+
+	if n.Func != "" {
+		return r.callFunc(n, input)
+	}
+
+	if r.ticks != nil {
+		select {
+		case <-r.ticks:
+		case <-r.ctx.Done():
+			return nil, "", r.ctx.Err()
+		}
+	}
+
+	opts := append([]api.RunOption(nil), r.executor.runOptions...)
+	if n.MaxRetries > 0 {
+		opts = append(opts, api.WithMaxRetries(n.MaxRetries))
+	}
+	if n.Timeout > 0 {
+		opts = append(opts, api.WithTimeout(n.Timeout))
+	}
+	result := r.executor.client.RunNoThrowContext(r.ctx, n.Model, input, opts...)
+	taskID := result.Detail.TaskID
+	if taskID == "unknown" {
+		taskID = ""
+	}
+	if result.Outputs == nil {
+		if err := r.ctx.Err(); err != nil {
+			return nil, taskID, err
+		}
+		return nil, taskID, errors.New(result.Detail.Error)
+	}
+	return result.Outputs, taskID, nil
+}
+
+func (r *run) callFunc(n Node, input map[string]any) (outputs []any, taskID string, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("func %s panicked: %v", n.Func, p)
+		}
+	}()
+	outputs, err = r.executor.funcs[n.Func](r.ctx, input)
+	return outputs, "", err
+}
@@ -0,0 +1,200 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/WaveSpeedAI/wavespeed-go/api"
+	"github.com/WaveSpeedAI/wavespeed-go/api/apitest"
+)
+
+func TestExecutorRun(t *testing.T) {
+	mock := apitest.NewMock()
+	mock.Enqueue("wavespeed-ai/z-image/turbo", apitest.Result{TaskID: "gen", Outputs: []any{"a.png", "b.png", "c.png"}})
+	mock.Default = apitest.Result{Outputs: []any{"upscaled.png"}}
+
+	var collage map[string]any
+	exec := NewExecutor(mock,
+		WithFunc("caption", func(ctx context.Context, input map[string]any) ([]any, error) {
+			return []any{"caption of " + input["image"].(string)}, nil
+		}),
+		WithFunc("collage", func(ctx context.Context, input map[string]any) ([]any, error) {
+			collage = input
+			return []any{"collage.png"}, nil
+		}),
+	)
+
+	def, err := Parse([]byte(testWorkflow))
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	result, err := exec.Run(context.Background(), def, map[string]any{"prompt": "Cat"})
+	if err != nil {
+		t.Fatalf("run error: %v", err)
+	}
+
+	if outputs := result.Outputs("compose"); len(outputs) != 1 || outputs[0] != "collage.png" {
+		t.Errorf("unexpected outputs: %v", outputs)
+	}
+	if images, _ := collage["images"].([]any); len(images) != 3 {
+		t.Errorf("expected the upscaled images to fan in, got %v", collage["images"])
+	}
+	if collage["title"] != "caption of a.png" {
+		t.Errorf("unexpected title: %v", collage["title"])
+	}
+
+	upscale := result.Nodes["upscale"]
+	if upscale.Status != NodeCompleted || len(upscale.TaskIDs) != 3 || len(upscale.Outputs) != 3 {
+		t.Errorf("unexpected upscale result: %+v", upscale)
+	}
+	names := make(map[any]bool)
+	for _, call := range mock.Calls() {
+		if call.Model == "wavespeed-ai/image-upscaler" {
+			names[call.Input["name"]] = true
+		}
+	}
+	if !names["variation 0"] || !names["variation 2"] {
+		t.Errorf("expected one upscale call per variation, got %v", names)
+	}
+	if mock.Calls()[0].Input["prompt"] != "Cat" || mock.Calls()[0].Input["num_images"] != float64(3) {
+		t.Errorf("unexpected generate input: %v", mock.Calls()[0].Input)
+	}
+}
+
+func TestExecutorFailureSkipsDependents(t *testing.T) {
+	mock := apitest.NewMock()
+	mock.Enqueue("fail-model", apitest.Result{TaskID: "t1", Err: errors.New("model overloaded")})
+	mock.Default = apitest.Result{Outputs: []any{"ok.png"}}
+
+	def := &Definition{Nodes: []Node{
+		{ID: "a", Model: "fail-model"},
+		{ID: "b", Model: "other-model", Input: map[string]any{"image": "{{a.output}}"}},
+		{ID: "c", Model: "other-model", DependsOn: []string{"b"}},
+		{ID: "d", Model: "other-model"},
+	}}
+	result, err := NewExecutor(mock).Run(context.Background(), def, nil)
+
+	var nodeErr *NodeError
+	if !errors.As(err, &nodeErr) || nodeErr.Node != "a" || !strings.Contains(err.Error(), "model overloaded") {
+		t.Fatalf("expected node a error, got %v", err)
+	}
+	if result.Nodes["a"].Status != NodeFailed || result.Nodes["a"].TaskIDs[0] != "t1" {
+		t.Errorf("unexpected result for a: %+v", result.Nodes["a"])
+	}
+	if result.Nodes["b"].Status != NodeSkipped || result.Nodes["c"].Status != NodeSkipped {
+		t.Errorf("expected dependents to be skipped, got %+v, %+v", result.Nodes["b"], result.Nodes["c"])
+	}
+	if result.Nodes["d"].Status != NodeCompleted {
+		t.Errorf("expected independent node to complete, got %+v", result.Nodes["d"])
+	}
+	if calls := mock.CallsTo("RunNoThrow"); len(calls) != 2 {
+		t.Errorf("expected 2 model calls, got %d", len(calls))
+	}
+}
+
+func TestExecutorConcurrency(t *testing.T) {
+	var mu sync.Mutex
+	active, peak := 0, 0
+	slow := func(ctx context.Context, input map[string]any) ([]any, error) {
+		mu.Lock()
+		active++
+		if active > peak {
+			peak = active
+		}
+		mu.Unlock()
+		time.Sleep(20 * time.Millisecond)
+		mu.Lock()
+		active--
+		mu.Unlock()
+		return []any{input["n"]}, nil
+	}
+
+	items := make([]any, 8)
+	for i := range items {
+		items[i] = i
+	}
+	def := &Definition{Nodes: []Node{
+		{ID: "list", Func: "list"},
+		{ID: "work", Func: "slow", ForEach: "{{list.outputs}}", Input: map[string]any{"n": "{{item}}"}},
+		{ID: "other", Func: "slow", Input: map[string]any{"n": "other"}},
+	}}
+	exec := NewExecutor(apitest.NewMock(),
+		WithFunc("list", func(ctx context.Context, input map[string]any) ([]any, error) { return items, nil }),
+		WithFunc("slow", slow),
+		WithConcurrency(3),
+	)
+	result, err := exec.Run(context.Background(), def, nil)
+	if err != nil {
+		t.Fatalf("run error: %v", err)
+	}
+	if peak != 3 {
+		t.Errorf("expected at most and at least 3 concurrent calls, got %d", peak)
+	}
+	if outputs := result.Outputs("work"); fmt.Sprint(outputs) != "[0 1 2 3 4 5 6 7]" {
+		t.Errorf("expected fan-out outputs in order, got %v", outputs)
+	}
+}
+
+func TestExecutorErrors(t *testing.T) {
+	exec := NewExecutor(apitest.NewMock(), WithFunc("boom", func(ctx context.Context, input map[string]any) ([]any, error) {
+		panic("boom")
+	}))
+
+	def := &Definition{Nodes: []Node{{ID: "a", Func: "missing"}}}
+	if _, err := exec.Run(context.Background(), def, nil); err == nil || !strings.Contains(err.Error(), "unknown func missing") {
+		t.Errorf("expected unknown func error, got %v", err)
+	}
+
+	def = &Definition{Nodes: []Node{{ID: "a", Func: "boom"}}}
+	if _, err := exec.Run(context.Background(), def, nil); err == nil || !strings.Contains(err.Error(), "panicked: boom") {
+		t.Errorf("expected panic to be reported, got %v", err)
+	}
+
+	def = &Definition{Nodes: []Node{{ID: "a", Model: "m", Input: map[string]any{"prompt": "{{input.prompt}}"}}}}
+	if _, err := exec.Run(context.Background(), def, nil); err == nil || !strings.Contains(err.Error(), "missing workflow input: prompt") {
+		t.Errorf("expected missing input error, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	def = &Definition{Nodes: []Node{{ID: "a", Model: "m"}}}
+	if _, err := exec.Run(ctx, def, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+// blockingRunner is a Runner whose model calls last until ctx is done.
+type blockingRunner struct {
+	*apitest.Mock
+}
+
+func (r blockingRunner) RunNoThrowContext(ctx context.Context, model string, input map[string]any, opts ...api.RunOption) *api.RunNoThrowResult {
+	<-ctx.Done()
+	return r.Mock.RunNoThrowContext(ctx, model, input, opts...)
+}
+
+func TestExecutorRunCancelsModelCalls(t *testing.T) {
+	def := &Definition{Nodes: []Node{{ID: "a", Model: "slow-model"}}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		_, err := NewExecutor(blockingRunner{apitest.NewMock()}).Run(ctx, def, nil)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("run did not stop after its context was cancelled")
+	}
+}
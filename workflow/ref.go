@@ -0,0 +1,216 @@
+package workflow
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// reference is a parsed {{...}} reference:
+//
+//	{{input}}, {{input.prompt}}   the workflow input or one of its fields
+//	{{item}}, {{item.url}}        the current ForEach item or one of its fields
+//	{{index}}                     the position of the current ForEach item
+//	{{node.outputs}}              all outputs of a node
+//	{{node.outputs.2}}            one output of a node
+//	{{node.output}}               the first output of a node
+type reference struct {
+	node  string
+	field string
+	index int
+}
+
+func parseRef(expr string) (reference, error) {
+	parts := strings.Split(strings.TrimSpace(expr), ".")
+	ref := reference{node: parts[0], index: -1}
+	if ref.node == "" {
+		return ref, fmt.Errorf("empty reference")
+	}
+
+	switch ref.node {
+	case "input", "item":
+		if len(parts) > 2 {
+			return ref, fmt.Errorf("invalid reference {{%s}}", expr)
+		}
+		if len(parts) == 2 {
+			ref.field = parts[1]
+		}
+		return ref, nil
+	case "index":
+		if len(parts) != 1 {
+			return ref, fmt.Errorf("invalid reference {{%s}}", expr)
+		}
+		return ref, nil
+	}
+
+	if len(parts) < 2 || (parts[1] != "outputs" && parts[1] != "output") {
+		return ref, fmt.Errorf("invalid reference {{%s}}: expected %s.outputs or %s.output", expr, ref.node, ref.node)
+	}
+	ref.field = parts[1]
+	switch {
+	case len(parts) == 2 && ref.field == "output":
+		ref.index = 0
+	case len(parts) == 2:
+	case len(parts) == 3 && ref.field == "outputs":
+		i, err := strconv.Atoi(parts[2])
+		if err != nil || i < 0 {
+			return ref, fmt.Errorf("invalid output index in {{%s}}", expr)
+		}
+		ref.index = i
+	default:
+		return ref, fmt.Errorf("invalid reference {{%s}}", expr)
+	}
+	return ref, nil
+}
+
+// parseWholeRef parses s if it consists of a single reference.
+func parseWholeRef(s string) (reference, bool, error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "{{") || !strings.HasSuffix(s, "}}") || strings.Count(s, "{{") != 1 {
+		return reference{}, false, nil
+	}
+	ref, err := parseRef(s[2 : len(s)-2])
+	return ref, err == nil, err
+}
+
+// splitRefs calls literal for the text between references and ref for each
+// reference in s.
+func splitRefs(s string, literal func(string), ref func(reference) error) error {
+	for {
+		start := strings.Index(s, "{{")
+		if start < 0 {
+			literal(s)
+			return nil
+		}
+		end := strings.Index(s[start:], "}}")
+		if end < 0 {
+			return fmt.Errorf("unterminated reference in %q", s)
+		}
+		literal(s[:start])
+		r, err := parseRef(s[start+2 : start+end])
+		if err != nil {
+			return err
+		}
+		if err := ref(r); err != nil {
+			return err
+		}
+		s = s[start+end+2:]
+	}
+}
+
+// collectRefs appends the references found in the strings of v.
+func collectRefs(v any, refs *[]reference) error {
+	switch v := v.(type) {
+	case string:
+		return splitRefs(v, func(string) {}, func(r reference) error {
+			*refs = append(*refs, r)
+			return nil
+		})
+	case map[string]any:
+		for _, item := range v {
+			if err := collectRefs(item, refs); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for _, item := range v {
+			if err := collectRefs(item, refs); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// scope is what references resolve against.
+type scope struct {
+	input   map[string]any
+	outputs map[string][]any
+	item    any
+	index   int
+}
+
+func (s *scope) lookup(ref reference) (any, error) {
+	switch ref.node {
+	case "input":
+		if ref.field == "" {
+			return s.input, nil
+		}
+		v, ok := s.input[ref.field]
+		if !ok {
+			return nil, fmt.Errorf("missing workflow input: %s", ref.field)
+		}
+		return v, nil
+	case "item":
+		if ref.field == "" {
+			return s.item, nil
+		}
+		m, ok := s.item.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("item is not an object, cannot get %s", ref.field)
+		}
+		return m[ref.field], nil
+	case "index":
+		return s.index, nil
+	}
+
+	outputs, ok := s.outputs[ref.node]
+	if !ok {
+		return nil, fmt.Errorf("node %s has not completed", ref.node)
+	}
+	if ref.index < 0 {
+		return outputs, nil
+	}
+	if ref.index >= len(outputs) {
+		return nil, fmt.Errorf("node %s has %d outputs, no output %d", ref.node, len(outputs), ref.index)
+	}
+	return outputs[ref.index], nil
+}
+
+// resolve returns v with its references replaced. A string that is a single
+// reference is replaced by the referenced value, keeping its type; other
+// references are formatted into the surrounding text.
+func (s *scope) resolve(v any) (any, error) {
+	switch v := v.(type) {
+	case string:
+		if ref, ok, err := parseWholeRef(v); err != nil {
+			return nil, err
+		} else if ok {
+			return s.lookup(ref)
+		}
+		var b strings.Builder
+		err := splitRefs(v, func(text string) { b.WriteString(text) }, func(r reference) error {
+			value, err := s.lookup(r)
+			if err != nil {
+				return err
+			}
+			fmt.Fprint(&b, value)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return b.String(), nil
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, item := range v {
+			resolved, err := s.resolve(item)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", k, err)
+			}
+			out[k] = resolved
+		}
+		return out, nil
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			resolved, err := s.resolve(item)
+			if err != nil {
+				return nil, fmt.Errorf("%d: %w", i, err)
+			}
+			out[i] = resolved
+		}
+		return out, nil
+	}
+	return v, nil
+}
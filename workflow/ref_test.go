@@ -0,0 +1,45 @@
+package workflow
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	s := &scope{
+		input:   map[string]any{"prompt": "Cat", "count": 2},
+		outputs: map[string][]any{"gen": {"a.png", "b.png"}},
+		item:    map[string]any{"url": "c.png"},
+		index:   1,
+	}
+	got, err := s.resolve(map[string]any{
+		"all":    "{{gen.outputs}}",
+		"first":  "{{ gen.output }}",
+		"second": "{{gen.outputs.1}}",
+		"count":  "{{input.count}}",
+		"text":   "{{input.prompt}} #{{index}} from {{item.url}}",
+		"nested": []any{map[string]any{"url": "{{item.url}}"}, 3},
+	})
+	if err != nil {
+		t.Fatalf("resolve error: %v", err)
+	}
+	want := map[string]any{
+		"all":    []any{"a.png", "b.png"},
+		"first":  "a.png",
+		"second": "b.png",
+		"count":  2,
+		"text":   "Cat #1 from c.png",
+		"nested": []any{map[string]any{"url": "c.png"}, 3},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected input:\n got %v\nwant %v", got, want)
+	}
+
+	if _, err := s.resolve("{{gen.outputs.5}}"); err == nil || !strings.Contains(err.Error(), "has 2 outputs") {
+		t.Errorf("expected output index error, got %v", err)
+	}
+	if _, err := s.resolve("{{input.missing}}"); err == nil || !strings.Contains(err.Error(), "missing workflow input") {
+		t.Errorf("expected missing input error, got %v", err)
+	}
+}
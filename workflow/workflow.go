@@ -0,0 +1,247 @@
+// Package workflow runs DAGs of WaveSpeed model calls and Go functions.
+//
+// A workflow is a list of nodes. Each node either runs a model or calls a
+// function registered with the Executor, and builds its input from the
+// workflow input and the outputs of other nodes through references such as
+// "{{generate.outputs}}". Nodes run as soon as the nodes they reference have
+// completed, so independent branches run concurrently. A node with ForEach
+// fans out into one call per item of a list, and a later node can fan the
+// results back in.
+//
+// Definitions encode to JSON, so tools outside Go can submit workflows:
+//
+//	{
+//	  "nodes": [
+//	    {"id": "generate", "model": "wavespeed-ai/z-image/turbo",
+//	     "input": {"prompt": "{{input.prompt}}", "num_images": 4}},
+//	    {"id": "upscale", "model": "wavespeed-ai/image-upscaler",
+//	     "for_each": "{{generate.outputs}}", "input": {"image": "{{item}}"}},
+//	    {"id": "compose", "func": "collage",
+//	     "input": {"images": "{{upscale.outputs}}"}}
+//	  ]
+//	}
+//
+// Example:
+//
+//	def, err := workflow.Parse(data)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	exec := workflow.NewExecutor(client,
+//	    workflow.WithFunc("collage", collage),
+//	    workflow.WithConcurrency(8),
+//	)
+//	result, err := exec.Run(ctx, def, map[string]any{"prompt": "Cat"})
+//	fmt.Println(result.Outputs("compose"))
+package workflow
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/WaveSpeedAI/wavespeed-go/internal/yamlsubset"
+)
+
+// Definition is a serializable workflow.
+type Definition struct {
+	Nodes []Node `json:"nodes"`
+}
+
+// Node is a single step of a workflow. Exactly one of Model and Func is set.
+type Node struct {
+	ID string `json:"id"`
+	// Model is the model to run, e.g. "wavespeed-ai/z-image/turbo".
+	Model string `json:"model,omitempty"`
+	// Func names a function registered with WithFunc.
+	Func string `json:"func,omitempty"`
+	// Input is the node input. String values may hold references such as
+	// "{{input.prompt}}" or "{{generate.outputs.0}}", resolved when the node
+	// runs. A string that is a single reference is replaced by the value
+	// itself, so "{{generate.outputs}}" becomes a list.
+	Input map[string]any `json:"input,omitempty"`
+	// ForEach is a reference to a list. The node runs once per item, with the
+	// item available as {{item}} and its position as {{index}}, and its
+	// outputs are the outputs of every call in order.
+	ForEach string `json:"for_each,omitempty"`
+	// DependsOn lists nodes that must complete first in addition to the ones
+	// referenced in Input and ForEach.
+	DependsOn []string `json:"depends_on,omitempty"`
+	// MaxRetries is the number of task-level retries for model nodes.
+	MaxRetries int `json:"max_retries,omitempty"`
+	// Timeout is the maximum time in seconds to wait for each model call.
+	Timeout float64 `json:"timeout,omitempty"`
+}
+
+// Parse decodes and validates a workflow definition in JSON or YAML. A
+// document starting with "{" is read as JSON; anything else as YAML, with
+// the same field names:
+//
+//	nodes:
+//	  - id: generate
+//	    model: wavespeed-ai/z-image/turbo
+//	    input:
+//	      prompt: "{{input.prompt}}"
+//	  - id: upscale
+//	    model: wavespeed-ai/image-upscaler
+//	    for_each: "{{generate.outputs}}"
+//	    input:
+//	      image: "{{item}}"
+//
+// YAML support covers block mappings and sequences, lists written as
+// [a, b], and scalars. References must be quoted, since they start with "{".
+func Parse(data []byte) (*Definition, error) {
+	if text := strings.TrimSpace(string(data)); !strings.HasPrefix(text, "{") {
+		value, err := yamlsubset.Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse workflow: %w", err)
+		}
+		if _, ok := value.(map[string]any); !ok {
+			return nil, errors.New("failed to parse workflow: expected a mapping with nodes")
+		}
+		if data, err = json.Marshal(value); err != nil {
+			return nil, fmt.Errorf("failed to parse workflow: %w", err)
+		}
+	}
+
+	var def Definition
+	if err := json.Unmarshal(data, &def); err != nil {
+		return nil, fmt.Errorf("failed to parse workflow: %w", err)
+	}
+	if err := def.Validate(); err != nil {
+		return nil, err
+	}
+	return &def, nil
+}
+
+// Validate checks that node IDs are unique, every node has either a model or
+// a function, every reference names a known node and there are no cycles.
+func (d *Definition) Validate() error {
+	if len(d.Nodes) == 0 {
+		return errors.New("workflow has no nodes")
+	}
+
+	ids := make(map[string]bool, len(d.Nodes))
+	for i, n := range d.Nodes {
+		if n.ID == "" {
+			return fmt.Errorf("node %d has no id", i+1)
+		}
+		if n.ID == "input" || n.ID == "item" || n.ID == "index" {
+			return fmt.Errorf("node id %q is reserved", n.ID)
+		}
+		if strings.ContainsAny(n.ID, ".{} ") {
+			return fmt.Errorf("node id %q must not contain dots, braces or spaces", n.ID)
+		}
+		if ids[n.ID] {
+			return fmt.Errorf("duplicate node id: %s", n.ID)
+		}
+		ids[n.ID] = true
+		if (n.Model == "") == (n.Func == "") {
+			return fmt.Errorf("node %s must set exactly one of model and func", n.ID)
+		}
+	}
+
+	for _, n := range d.Nodes {
+		deps, err := n.dependencies()
+		if err != nil {
+			return fmt.Errorf("node %s: %w", n.ID, err)
+		}
+		for _, dep := range deps {
+			if !ids[dep] {
+				return fmt.Errorf("node %s depends on unknown node %s", n.ID, dep)
+			}
+		}
+	}
+
+	if _, err := d.order(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// dependencies returns the IDs of the nodes n depends on, sorted.
+func (n Node) dependencies() ([]string, error) {
+	seen := make(map[string]bool)
+	for _, dep := range n.DependsOn {
+		seen[dep] = true
+	}
+
+	var refs []reference
+	if err := collectRefs(n.Input, &refs); err != nil {
+		return nil, err
+	}
+	if n.ForEach != "" {
+		ref, ok, err := parseWholeRef(n.ForEach)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, fmt.Errorf("for_each must be a single reference, got %q", n.ForEach)
+		}
+		refs = append(refs, ref)
+	}
+	for _, ref := range refs {
+		if ref.node == "item" || ref.node == "index" {
+			if n.ForEach == "" {
+				return nil, fmt.Errorf("{{%s}} is only available with for_each", ref.node)
+			}
+			continue
+		}
+		if ref.node != "input" {
+			seen[ref.node] = true
+		}
+	}
+
+	deps := make([]string, 0, len(seen))
+	for dep := range seen {
+		deps = append(deps, dep)
+	}
+	sort.Strings(deps)
+	return deps, nil
+}
+
+// order returns the node IDs in a topological order, or an error naming a
+// node on a cycle.
+func (d *Definition) order() ([]string, error) {
+	indegree := make(map[string]int, len(d.Nodes))
+	dependents := make(map[string][]string)
+	for _, n := range d.Nodes {
+		deps, err := n.dependencies()
+		if err != nil {
+			return nil, err
+		}
+		indegree[n.ID] = len(deps)
+		for _, dep := range deps {
+			dependents[dep] = append(dependents[dep], n.ID)
+		}
+	}
+
+	var queue, order []string
+	for _, n := range d.Nodes {
+		if indegree[n.ID] == 0 {
+			queue = append(queue, n.ID)
+		}
+	}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		order = append(order, id)
+		for _, next := range dependents[id] {
+			indegree[next]--
+			if indegree[next] == 0 {
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	if len(order) != len(d.Nodes) {
+		for _, n := range d.Nodes {
+			if indegree[n.ID] > 0 {
+				return nil, fmt.Errorf("workflow has a cycle through node %s", n.ID)
+			}
+		}
+	}
+	return order, nil
+}
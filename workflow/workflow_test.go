@@ -0,0 +1,81 @@
+package workflow
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testWorkflow = `{
+  "nodes": [
+    {"id": "generate", "model": "wavespeed-ai/z-image/turbo",
+     "input": {"prompt": "{{input.prompt}}", "num_images": 3}},
+    {"id": "upscale", "model": "wavespeed-ai/image-upscaler",
+     "for_each": "{{generate.outputs}}", "input": {"image": "{{item}}", "name": "variation {{index}}"}},
+    {"id": "caption", "func": "caption",
+     "input": {"image": "{{generate.output}}"}},
+    {"id": "compose", "func": "collage",
+     "input": {"images": "{{upscale.outputs}}", "title": "{{caption.outputs.0}}"}}
+  ]
+}`
+
+func TestParse(t *testing.T) {
+	def, err := Parse([]byte(testWorkflow))
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if len(def.Nodes) != 4 || def.Nodes[1].ForEach != "{{generate.outputs}}" {
+		t.Errorf("unexpected definition: %+v", def)
+	}
+
+	deps, err := def.Nodes[3].dependencies()
+	if err != nil || !reflect.DeepEqual(deps, []string{"caption", "upscale"}) {
+		t.Errorf("unexpected dependencies: %v, %v", deps, err)
+	}
+	order, err := def.order()
+	if err != nil || order[0] != "generate" || order[3] != "compose" {
+		t.Errorf("unexpected order: %v, %v", order, err)
+	}
+
+	// Definitions survive a round trip through JSON.
+	data, err := json.Marshal(def)
+	if err != nil {
+		t.Fatalf("failed to encode definition: %v", err)
+	}
+	if _, err := Parse(data); err != nil {
+		t.Errorf("failed to parse encoded definition: %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		def  string
+		want string
+	}{
+		{"empty", `{"nodes": []}`, "no nodes"},
+		{"duplicate", `{"nodes": [{"id": "a", "model": "m"}, {"id": "a", "model": "m"}]}`, "duplicate node id: a"},
+		{"reserved", `{"nodes": [{"id": "input", "model": "m"}]}`, "reserved"},
+		{"dotted", `{"nodes": [{"id": "a.b", "model": "m"}]}`, "must not contain"},
+		{"no kind", `{"nodes": [{"id": "a"}]}`, "exactly one of model and func"},
+		{"both kinds", `{"nodes": [{"id": "a", "model": "m", "func": "f"}]}`, "exactly one of model and func"},
+		{"unknown ref", `{"nodes": [{"id": "a", "model": "m", "input": {"x": "{{b.outputs}}"}}]}`, "unknown node b"},
+		{"unknown dep", `{"nodes": [{"id": "a", "model": "m", "depends_on": ["b"]}]}`, "unknown node b"},
+		{"bad ref", `{"nodes": [{"id": "a", "model": "m", "input": {"x": "{{a.result}}"}}]}`, "invalid reference"},
+		{"unterminated", `{"nodes": [{"id": "a", "model": "m", "input": {"x": "{{input.prompt"}}]}`, "unterminated"},
+		{"item without for_each", `{"nodes": [{"id": "a", "model": "m", "input": {"x": "{{item}}"}}]}`, "only available with for_each"},
+		{"for_each text", `{"nodes": [{"id": "a", "model": "m", "for_each": "images"}]}`, "single reference"},
+		{"self cycle", `{"nodes": [{"id": "a", "model": "m", "input": {"x": "{{a.output}}"}}]}`, "cycle through node a"},
+		{"cycle", `{"nodes": [
+			{"id": "a", "model": "m", "input": {"x": "{{b.output}}"}},
+			{"id": "b", "model": "m", "input": {"x": "{{a.output}}"}}]}`, "cycle"},
+		{"invalid json", `{"nodes": [`, "failed to parse workflow"},
+	}
+	for _, tt := range tests {
+		_, err := Parse([]byte(tt.def))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: expected error containing %q, got %v", tt.name, tt.want, err)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package workflow
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testYAMLWorkflow = `# Same workflow as testWorkflow.
+nodes:
+  - id: generate
+    model: wavespeed-ai/z-image/turbo
+    input:
+      prompt: "{{input.prompt}}"
+      num_images: 3
+  - id: upscale
+    model: wavespeed-ai/image-upscaler
+    for_each: '{{generate.outputs}}'
+    input:
+      image: "{{item}}"
+      name: "variation {{index}}"   # a comment
+  - id: caption
+    func: caption
+    input:
+      image: "{{generate.output}}"
+  - id: compose
+    func: collage
+    input:
+      images: "{{upscale.outputs}}"
+      title: "{{caption.outputs.0}}"
+`
+
+func TestParseYAML(t *testing.T) {
+	fromJSON, err := Parse([]byte(testWorkflow))
+	if err != nil {
+		t.Fatalf("parse JSON error: %v", err)
+	}
+	fromYAML, err := Parse([]byte(testYAMLWorkflow))
+	if err != nil {
+		t.Fatalf("parse YAML error: %v", err)
+	}
+	if !reflect.DeepEqual(fromJSON, fromYAML) {
+		t.Errorf("YAML and JSON definitions differ:\n%+v\n%+v", fromYAML, fromJSON)
+	}
+}
+
+func TestParseYAMLErrors(t *testing.T) {
+	tests := []struct {
+		doc  string
+		want string
+	}{
+		{"nodes:\n  - id: a\n    input: {prompt: x}\n", "flow mappings are not supported"},
+		{"nodes:\n  - id: a\n    input:\n      prompt: {{input.prompt}}\n", "quote strings"},
+		{"nodes:\n  - id: a\n     model: m\n", "line 3: unexpected indentation"},
+		{"nodes:\n  id: a\n  id: b\n", `duplicate key "id"`},
+		{"prompt: |\n  text\n", "block scalars are not supported"},
+		{"- id: a\n", "expected a mapping with nodes"},
+		{"nodes:\n  - id: a\n    model: m\n    func: f\n", "exactly one of model and func"},
+	}
+	for _, tt := range tests {
+		if _, err := Parse([]byte(tt.doc)); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Parse(%q): expected error containing %q, got %v", tt.doc, tt.want, err)
+		}
+	}
+}